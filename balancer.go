@@ -0,0 +1,169 @@
+package rbns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	_ "google.golang.org/grpc/health" // client side health checking
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// BalancerPolicy chooses between the ready endpoints of the preferred zone.
+type BalancerPolicy string
+
+const (
+	// RoundRobin cycles through the endpoints.
+	RoundRobin BalancerPolicy = "rbns_round_robin"
+	// LeastLoaded picks the endpoint with the fewest RPCs in flight.
+	LeastLoaded BalancerPolicy = "rbns_least_loaded"
+)
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(string(RoundRobin), &pickerBuilder{policy: RoundRobin}, base.Config{HealthCheck: true}))
+	balancer.Register(base.NewBalancerBuilder(string(LeastLoaded), &pickerBuilder{policy: LeastLoaded}, base.Config{HealthCheck: true}))
+}
+
+// Endpoint is one rbns server and the zone it runs in.
+type Endpoint struct {
+	Address string
+	Zone    string
+}
+
+var (
+	ErrConflictingTargets = errors.New("rbns: WithEndpoints cannot be combined with WithHost or WithDNS")
+)
+
+// WithEndpoints connects to several servers instead of WithHost. Requests
+// are balanced over the endpoints of the local zone set by WithZone and fail
+// over to the other zones when none of them is healthy. Connection fails
+// with ErrConflictingTargets when WithHost or WithDNS is also given.
+func WithEndpoints(endpoints ...Endpoint) Option {
+	return func(conf *config) {
+		conf.endpoints = append(conf.endpoints, endpoints...)
+	}
+}
+
+// WithDNS connects to every address a DNS name resolves to, as
+// "host:port".
+func WithDNS(name string) Option {
+	return func(conf *config) {
+		conf.host = "dns:///" + name
+		conf.hostSet = true
+	}
+}
+
+// WithZone sets the zone the client runs in.
+func WithZone(zone string) Option {
+	return func(conf *config) {
+		conf.zone = zone
+	}
+}
+
+// WithBalancer sets the balancing policy, RoundRobin by default. Endpoints
+// failing the gRPC health check are ejected until they serve again.
+func WithBalancer(policy BalancerPolicy) Option {
+	return func(conf *config) {
+		conf.balancer = policy
+	}
+}
+
+type localZoneKey struct{}
+
+var resolverSeq uint64
+
+// balancing returns the target and dial options implementing the endpoint,
+// zone and balancer settings of conf.
+func (conf *config) balancing() (string, []grpc.DialOption) {
+	policy := conf.balancer
+	if policy == "" {
+		policy = RoundRobin
+	}
+	opts := []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{%q:{}}],"healthCheckConfig":{"serviceName":""}}`, policy)),
+	}
+	if len(conf.endpoints) == 0 {
+		if conf.balancer == "" && !strings.HasPrefix(conf.host, "dns:") {
+			return conf.host, nil
+		}
+		return conf.host, opts
+	}
+	r := manual.NewBuilderWithScheme(fmt.Sprintf("rbns-%d", atomic.AddUint64(&resolverSeq, 1)))
+	addrs := make([]resolver.Address, len(conf.endpoints))
+	for i, e := range conf.endpoints {
+		addrs[i] = resolver.Address{
+			Addr:       e.Address,
+			Attributes: attributes.New(localZoneKey{}, conf.zone == "" || e.Zone == conf.zone),
+		}
+	}
+	r.InitialState(resolver.State{Addresses: addrs})
+	return r.Scheme() + ":///rbns", append(opts, grpc.WithResolvers(r))
+}
+
+type pickerBuilder struct {
+	policy BalancerPolicy
+}
+
+type pickerConn struct {
+	subConn  balancer.SubConn
+	inflight int64
+}
+
+func (b *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	var local, remote []*pickerConn
+	for sc, sci := range info.ReadySCs {
+		c := &pickerConn{subConn: sc}
+		// Addresses without the attribute, as from DNS, count as local.
+		if v, ok := sci.Address.Attributes.Value(localZoneKey{}).(bool); ok && !v {
+			remote = append(remote, c)
+		} else {
+			local = append(local, c)
+		}
+	}
+	conns := local
+	if len(conns) == 0 {
+		conns = remote
+	}
+	return &picker{policy: b.policy, conns: conns}
+}
+
+type picker struct {
+	policy BalancerPolicy
+	conns  []*pickerConn
+	next   uint64
+	mu     sync.Mutex
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	if p.policy != LeastLoaded {
+		c := p.conns[atomic.AddUint64(&p.next, 1)%uint64(len(p.conns))]
+		return balancer.PickResult{SubConn: c.subConn}, nil
+	}
+	p.mu.Lock()
+	// Start from a rotating offset so that ties are spread as well.
+	start := int(p.next % uint64(len(p.conns)))
+	p.next++
+	c := p.conns[start]
+	for i := 1; i < len(p.conns); i++ {
+		if other := p.conns[(start+i)%len(p.conns)]; other.inflight < c.inflight {
+			c = other
+		}
+	}
+	c.inflight++
+	p.mu.Unlock()
+	return balancer.PickResult{SubConn: c.subConn, Done: func(balancer.DoneInfo) {
+		p.mu.Lock()
+		c.inflight--
+		p.mu.Unlock()
+	}}, nil
+}
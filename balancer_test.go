@@ -0,0 +1,98 @@
+package rbns_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func balancedServers(t *testing.T, addrs ...string) (map[string]*tests.Server, grpc.DialOption) {
+	servers := map[string]*tests.Server{}
+	for _, addr := range addrs {
+		srv := tests.NewServer()
+		srv.AddPermission("read:test", "")
+		srv.AddRole("reader", "read:test")
+		srv.AddOrganization("default")
+		srv.AddUser("default", "user1", "reader")
+		t.Cleanup(srv.Close)
+		servers[addr] = srv
+	}
+	dialer := grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return servers[addr].Dial(ctx, addr)
+	})
+	return servers, dialer
+}
+
+func balancedClient(t *testing.T, policy rbns.BalancerPolicy) map[string]*tests.Server {
+	servers, dialer := balancedServers(t, "a", "b", "c")
+	client, err := rbns.Connection(context.Background(),
+		rbns.WithEndpoints(rbns.Endpoint{"a", "zone1"}, rbns.Endpoint{"b", "zone1"}, rbns.Endpoint{"c", "zone2"}),
+		rbns.WithZone("zone1"), rbns.WithBalancer(policy),
+		rbns.WithDialOption(dialer, grpc.WithInsecure()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	// Subconns become ready one by one, so keep checking until both local
+	// endpoints served a request.
+	waitFor(t, func() bool {
+		ok, err := client.Check("user1", "default", "read:test")
+		require.NoError(t, err)
+		require.True(t, ok)
+		return servers["a"].Checks() > 0 && servers["b"].Checks() > 0
+	})
+	return servers
+}
+
+func TestBalancerLocalZone(t *testing.T) {
+	for _, policy := range []rbns.BalancerPolicy{rbns.RoundRobin, rbns.LeastLoaded} {
+		t.Run(string(policy), func(t *testing.T) {
+			servers := balancedClient(t, policy)
+			assert.Zero(t, servers["c"].Checks())
+		})
+	}
+}
+
+func TestBalancerFailover(t *testing.T) {
+	servers, dialer := balancedServers(t, "a", "b", "c")
+	client, err := rbns.Connection(context.Background(),
+		rbns.WithEndpoints(rbns.Endpoint{"a", "zone1"}, rbns.Endpoint{"b", "zone1"}, rbns.Endpoint{"c", "zone2"}),
+		rbns.WithZone("zone1"),
+		rbns.WithDialOption(dialer, grpc.WithInsecure()))
+	require.NoError(t, err)
+	defer client.Close()
+
+	servers["a"].Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	servers["b"].Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	waitFor(t, func() bool {
+		_, _ = client.Check("user1", "default", "read:test")
+		return servers["c"].Checks() > 0
+	})
+	a, b := servers["a"].Checks(), servers["b"].Checks()
+	for i := 0; i < 10; i++ {
+		ok, err := client.Check("user1", "default", "read:test")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, a, servers["a"].Checks())
+	assert.Equal(t, b, servers["b"].Checks())
+
+	servers["a"].Health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	waitFor(t, func() bool {
+		_, _ = client.Check("user1", "default", "read:test")
+		return servers["a"].Checks() > a
+	})
+}
+
+func TestConflictingTargets(t *testing.T) {
+	endpoints := rbns.WithEndpoints(rbns.Endpoint{"a", "zone1"})
+	for _, opt := range []rbns.Option{rbns.WithHost("localhost:6565"), rbns.WithDNS("rbns:6565")} {
+		_, err := rbns.Connection(context.Background(), opt, endpoints)
+		assert.Equal(t, rbns.ErrConflictingTargets, err)
+	}
+}
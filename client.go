@@ -3,9 +3,9 @@ package rbns
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
-	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -19,9 +19,17 @@ var defaultConfig = &config{
 }
 
 type config struct {
-	dialOptions []grpc.DialOption
-	apiKey      string
-	host        string
+	dialOptions  []grpc.DialOption
+	apiKey       string
+	host         string
+	hostSet      bool
+	effectiveTTL time.Duration
+	coalesce     bool
+	endpoints    []Endpoint
+	zone         string
+	balancer     BalancerPolicy
+	nonBlocking  bool
+	startupWait  time.Duration
 }
 
 type Option func(conf *config)
@@ -38,31 +46,90 @@ func WithApiKey(apiKey string) Option {
 	}
 }
 
+// WithHost sets the server to connect to. It cannot be combined with
+// WithEndpoints.
 func WithHost(host string) Option {
 	return func(conf *config) {
 		conf.host = host
+		conf.hostSet = true
+	}
+}
+
+// WithEffectivePermissionsCache caches EffectivePermissions results for ttl.
+func WithEffectivePermissionsCache(ttl time.Duration) Option {
+	return func(conf *config) {
+		conf.effectiveTTL = ttl
+	}
+}
+
+// WithCheckCoalescing makes identical concurrent checks share one RPC.
+func WithCheckCoalescing() Option {
+	return func(conf *config) {
+		conf.coalesce = true
 	}
 }
 
 type Client struct {
-	con  *grpc.ClientConn
-	ctx  context.Context
-	conf config
+	con       *grpc.ClientConn
+	ctx       context.Context
+	conf      config
+	effective *effectiveCache
+	checks    *checkGroup
+	readiness *readiness
 }
 
 func (c *Client) Close() error {
+	if c.readiness != nil {
+		c.readiness.close()
+	}
 	if c.con != nil {
 		return c.con.Close()
 	}
 	return nil
 }
 
-func (c *Client) permission() PermissionClient {
-	return newPermission(c.con, c.ctx)
+// Conn returns the underlying connection for use with the generated clients in the proto package.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.con
+}
+
+// OutgoingContext attaches the client's credentials to ctx.
+func (c *Client) OutgoingContext(ctx context.Context) context.Context {
+	if c.conf.apiKey == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", c.conf.apiKey)
 }
 
 func (c *Client) Check(userKey, organizationName string, permissionNames ...string) (bool, error) {
-	return c.permission().Check(userKey, organizationName, permissionNames...)
+	return c.check(c.ctx, userKey, organizationName, permissionNames)
+}
+
+// CheckContext is Check bounded by ctx.
+func (c *Client) CheckContext(ctx context.Context, userKey, organizationName string, permissionNames ...string) (bool, error) {
+	return c.check(c.OutgoingContext(ctx), userKey, organizationName, permissionNames)
+}
+
+func (c *Client) check(ctx context.Context, userKey, organizationName string, permissionNames []string) (bool, error) {
+	if err := c.readyErr(); err != nil {
+		return false, err
+	}
+	if c.checks == nil {
+		return newPermission(c.con, ctx).Check(userKey, organizationName, permissionNames...)
+	}
+	key := checkKey(userKey, organizationName, permissionNames)
+	return c.checks.do(ctx, key, func(ctx context.Context) (bool, error) {
+		return newPermission(c.con, ctx).Check(userKey, organizationName, permissionNames...)
+	})
+}
+
+// CoalescingStats reports how many checks were coalesced. It is zero unless
+// the client was created with WithCheckCoalescing.
+func (c *Client) CoalescingStats() CoalescingStats {
+	if c.checks == nil {
+		return CoalescingStats{}
+	}
+	return c.checks.snapshot()
 }
 
 func Connection(ctx context.Context, opts ...Option) (*Client, error) {
@@ -75,22 +142,40 @@ func Connection(ctx context.Context, opts ...Option) (*Client, error) {
 		md := metadata.New(map[string]string{"authorization": conf.apiKey})
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
-	con, err := grpc.DialContext(ctx, conf.host, conf.dialOptions...)
-	if err != nil {
-		return nil, err
+	if conf.hostSet && len(conf.endpoints) > 0 {
+		return nil, ErrConflictingTargets
 	}
-	resp, err := healthpb.NewHealthClient(con).Check(ctx, &healthpb.HealthCheckRequest{
-		Service: "",
-	})
+	target, balancing := conf.balancing()
+	con, err := grpc.DialContext(ctx, target, append(balancing, conf.dialOptions...)...)
 	if err != nil {
-		return nil, fmt.Errorf("Status RPC failure: %s", err.Error())
+		return nil, err
 	}
-	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
-		return nil, fmt.Errorf("Status unhealthy: %s", resp.GetStatus().String())
+	if !conf.nonBlocking {
+		if err := healthCheck(ctx, con); err != nil {
+			return nil, err
+		}
 	}
-	return &Client{
+	client := &Client{
 		con:  con,
 		ctx:  ctx,
 		conf: *conf,
-	}, nil
+	}
+	if conf.effectiveTTL > 0 {
+		client.effective = newEffectiveCache(conf.effectiveTTL)
+	}
+	if conf.coalesce {
+		client.checks = newCheckGroup()
+	}
+	if conf.nonBlocking {
+		client.readiness = newReadiness()
+		go client.readiness.run(detachedContext{ctx}, con)
+		timer := time.NewTimer(conf.startupWait)
+		defer timer.Stop()
+		select {
+		case <-client.readiness.ready:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	return client, nil
 }
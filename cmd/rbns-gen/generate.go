@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/n-creativesystem/go-rbns/manifest"
+)
+
+type constant struct {
+	Ident       string
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type file struct {
+	Package     string
+	Source      string
+	Permissions []constant
+	Roles       []constant
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"sentence": func(s string) string { return strings.TrimSuffix(s, ".") },
+}).Parse(`// Code generated by rbns-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import rbns "github.com/n-creativesystem/go-rbns"
+{{if .Permissions}}
+const (
+{{- range .Permissions}}
+	// {{.Ident}} is {{printf "%q" .Name}}{{if .Description}}: {{sentence .Description}}{{end}}.
+	{{.Ident}} rbns.Permission = {{printf "%q" .Name}}
+{{- end}}
+)
+{{end}}{{if .Roles}}
+const (
+{{- range .Roles}}
+	// {{.Ident}} is {{printf "%q" .Name}}{{if .Description}}: {{sentence .Description}}{{end}}.
+	{{.Ident}} rbns.Role = {{printf "%q" .Name}}
+{{- end}}
+)
+{{end}}
+// Registry lists the permissions and roles with their descriptions and the
+// effective permissions of each role.
+var Registry = rbns.NewRegistry(
+	[]rbns.PermissionInfo{
+{{- range .Permissions}}
+		{Permission: {{.Ident}}, Description: {{printf "%q" .Description}}},
+{{- end}}
+	},
+	[]rbns.RoleInfo{
+{{- range .Roles}}
+		{Role: {{.Ident}}, Description: {{printf "%q" .Description}}, Permissions: []rbns.Permission{ {{- range $i, $p := .Permissions}}{{if $i}}, {{end}}{{$p}}{{end -}} }},
+{{- end}}
+	},
+)
+`))
+
+// identifier turns a permission or role name into an exported Go
+// identifier: create:test becomes CreateTest and read:* becomes ReadAny.
+// Names not starting with an upper case letter once converted, such as
+// those starting with a digit or a CJK character, are prefixed with P.
+func identifier(prefix, name string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '*':
+			b.WriteString("Any")
+			upper = true
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			upper = false
+		default:
+			upper = true
+		}
+	}
+	ident := b.String()
+	if ident == "" || !unicode.IsUpper([]rune(ident)[0]) {
+		ident = "P" + ident
+	}
+	return ident
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// generate renders the constants and registry of m as a formatted Go file.
+func generate(pkg, source string, m *manifest.Manifest) ([]byte, error) {
+	flat, err := m.Flatten()
+	if err != nil {
+		return nil, err
+	}
+	f := file{Package: pkg, Source: source}
+	idents := map[string]string{}
+	permissions := map[string]string{}
+	declare := func(ident, name string) error {
+		if other, ok := idents[ident]; ok {
+			return fmt.Errorf("rbns-gen: %q and %q both generate %s", other, name, ident)
+		}
+		idents[ident] = name
+		return nil
+	}
+	for _, p := range m.Permissions {
+		c := constant{Ident: identifier("", p.Name), Name: p.Name, Description: oneLine(p.Description)}
+		if err := declare(c.Ident, p.Name); err != nil {
+			return nil, err
+		}
+		permissions[p.Name] = c.Ident
+		f.Permissions = append(f.Permissions, c)
+	}
+	for _, r := range m.Roles {
+		c := constant{Ident: identifier("Role", r.Name), Name: r.Name, Description: oneLine(r.Description)}
+		if err := declare(c.Ident, r.Name); err != nil {
+			return nil, err
+		}
+		for _, p := range flat[r.Name] {
+			ident, ok := permissions[p]
+			if !ok {
+				return nil, fmt.Errorf("rbns-gen: role %q references unknown permission %q", r.Name, p)
+			}
+			c.Permissions = append(c.Permissions, ident)
+		}
+		f.Roles = append(f.Roles, c)
+	}
+	sort.Slice(f.Permissions, func(i, j int) bool { return f.Permissions[i].Name < f.Permissions[j].Name })
+	sort.Slice(f.Roles, func(i, j int) bool { return f.Roles[i].Name < f.Roles[j].Name })
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, f); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("rbns-gen: format: %w", err)
+	}
+	return src, nil
+}
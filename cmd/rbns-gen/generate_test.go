@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `
+permissions:
+  - name: create:test
+    description: create
+      tests
+  - name: read:test
+  - name: read:*
+roles:
+  - name: viewer
+    permissions: [read:test]
+  - name: site-admin
+    description: everything
+    permissions: [create:test, read:*]
+    inherits: [viewer]
+`
+
+func TestIdentifier(t *testing.T) {
+	cases := []struct {
+		prefix, name, want string
+	}{
+		{"", "create:test", "CreateTest"},
+		{"", "read:doc:comment", "ReadDocComment"},
+		{"", "read:*", "ReadAny"},
+		{"", "manage:APIKey", "ManageAPIKey"},
+		{"Role", "site-admin", "RoleSiteAdmin"},
+		{"", "2fa_enable", "P2faEnable"},
+		{"", "閲覧:文書", "P閲覧文書"},
+		{"", "_:test", "Test"},
+		{"Role", "管理者", "Role管理者"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, identifier(c.prefix, c.name), c.name)
+	}
+}
+
+func TestGenerateManifest(t *testing.T) {
+	m, err := manifest.ParseYAML([]byte(source))
+	require.NoError(t, err)
+	src, err := generate("perms", "rbns.yaml", m)
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "perms.go", src, parser.ParseComments)
+	require.NoError(t, err)
+
+	out := string(src)
+	assert.Contains(t, out, "// Code generated by rbns-gen from rbns.yaml. DO NOT EDIT.\n\npackage perms\n")
+	assert.Contains(t, out, "\t// CreateTest is \"create:test\": create tests.\n\tCreateTest rbns.Permission = \"create:test\"\n")
+	assert.Contains(t, out, "\tReadAny rbns.Permission = \"read:*\"\n")
+	assert.Contains(t, out, "\tRoleSiteAdmin rbns.Role = \"site-admin\"\n")
+	assert.Contains(t, out, "{Permission: CreateTest, Description: \"create tests\"},")
+	assert.Contains(t, out, "{Role: RoleSiteAdmin, Description: \"everything\", Permissions: []rbns.Permission{CreateTest, ReadAny, ReadTest}},")
+	assert.Contains(t, out, "{Role: RoleViewer, Description: \"\", Permissions: []rbns.Permission{ReadTest}},")
+}
+
+func TestGenerateCollision(t *testing.T) {
+	m := &manifest.Manifest{Permissions: []manifest.Permission{{Name: "read:test"}, {Name: "read-test"}}}
+	_, err := generate("perms", "rbns.yaml", m)
+	assert.EqualError(t, err, `rbns-gen: "read:test" and "read-test" both generate ReadTest`)
+}
+
+func TestGenerateServer(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "create tests")
+	srv.AddPermission("read:test", "")
+	srv.AddRole("editor", "create:test", "read:test")
+	client := srv.Client(t)
+	state, err := manifest.Fetch(context.Background(), client)
+	require.NoError(t, err)
+
+	src, err := generate("perms", srv.Addr(), &state.Manifest)
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "CreateTest rbns.Permission = \"create:test\"")
+	assert.Contains(t, string(src), "{Role: RoleEditor, Description: \"\", Permissions: []rbns.Permission{CreateTest, ReadTest}},")
+}
+
+func TestGenerateDescriptionPeriod(t *testing.T) {
+	m := &manifest.Manifest{Permissions: []manifest.Permission{{Name: "read:test", Description: "Read tests."}}}
+	src, err := generate("perms", "rbns.yaml", m)
+	require.NoError(t, err)
+	out := string(src)
+	assert.Contains(t, out, "\t// ReadTest is \"read:test\": Read tests.\n")
+	assert.Contains(t, out, "{Permission: ReadTest, Description: \"Read tests.\"},")
+}
@@ -0,0 +1,98 @@
+// Command rbns-gen generates a Go package of typed permission and role
+// constants, with a registry of their descriptions, from a manifest file or
+// from a live server.
+//
+//	rbns-gen -manifest rbns.yaml -package perms -o perms/perms.go
+//	rbns-gen -host localhost:6565 -insecure -api-key $KEY -package perms -o perms/perms.go
+//
+// Add a go:generate directive next to the output to keep it current.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		manifestPath = flag.String("manifest", "", "manifest file (.yaml, .yml or .json) to read")
+		host         = flag.String("host", "", "server to read with Permission.FindAll and Role.FindAll instead of a manifest")
+		apiKey       = flag.String("api-key", "", "API key of the server")
+		insecure     = flag.Bool("insecure", false, "connect to the server without TLS")
+		pkg          = flag.String("package", "", "package name, the output directory name by default")
+		output       = flag.String("o", "", "output file, standard output by default")
+		timeout      = flag.Duration("timeout", 30*time.Second, "timeout of the server calls")
+	)
+	flag.Parse()
+	if (*manifestPath == "") == (*host == "") {
+		return fmt.Errorf("rbns-gen: exactly one of -manifest and -host is required")
+	}
+	if *pkg == "" {
+		if *output == "" {
+			return fmt.Errorf("rbns-gen: -package is required when writing to standard output")
+		}
+		abs, err := filepath.Abs(*output)
+		if err != nil {
+			return err
+		}
+		*pkg = filepath.Base(filepath.Dir(abs))
+	}
+
+	var (
+		m      *manifest.Manifest
+		source string
+	)
+	if *manifestPath != "" {
+		var err error
+		if m, err = manifest.Load(*manifestPath); err != nil {
+			return err
+		}
+		source = filepath.Base(*manifestPath)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		opts := []rbns.Option{rbns.WithHost(*host)}
+		if *apiKey != "" {
+			opts = append(opts, rbns.WithApiKey(*apiKey))
+		}
+		if *insecure {
+			opts = append(opts, rbns.WithDialOption(grpc.WithInsecure()))
+		}
+		client, err := rbns.Connection(ctx, opts...)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		state, err := manifest.Fetch(ctx, client)
+		if err != nil {
+			return err
+		}
+		m = &state.Manifest
+		source = *host
+	}
+
+	src, err := generate(*pkg, source, m)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
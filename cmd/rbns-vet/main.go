@@ -0,0 +1,13 @@
+// Command rbns-vet runs the permcheck analyzer, on its own or as a vet tool:
+//
+//	go vet -vettool=$(which rbns-vet) -manifest=$PWD/rbns.yaml ./...
+package main
+
+import (
+	"github.com/n-creativesystem/go-rbns/permcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(permcheck.Analyzer)
+}
@@ -0,0 +1,115 @@
+package rbns
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CoalescingStats counts the checks going through request coalescing.
+type CoalescingStats struct {
+	// Calls is the number of checks made.
+	Calls uint64
+	// Coalesced is the number of checks that shared an RPC already in
+	// flight instead of issuing their own.
+	Coalesced uint64
+	// Canceled is the number of RPCs canceled because every check waiting
+	// on them had its context end.
+	Canceled uint64
+}
+
+// detachedContext keeps the values of its parent, such as the outgoing
+// metadata, but not its deadline or cancellation, so that a shared RPC does
+// not fail because the caller that started it went away.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+type checkCall struct {
+	done    chan struct{}
+	result  bool
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// checkGroup shares one in-flight RPC between identical concurrent checks.
+type checkGroup struct {
+	mu    sync.Mutex
+	calls map[string]*checkCall
+	stats CoalescingStats
+}
+
+func newCheckGroup() *checkGroup {
+	return &checkGroup{calls: map[string]*checkCall{}}
+}
+
+// checkKey identifies a check; the order of the permission names does not
+// change its answer.
+func checkKey(userKey, organizationName string, permissionNames []string) string {
+	names := append([]string{}, permissionNames...)
+	sort.Strings(names)
+	return userKey + "\x00" + organizationName + "\x00" + strings.Join(names, "\x00")
+}
+
+// do waits for the RPC in flight for key, starting it with fn if there is
+// none. A waiter whose context ends returns at once; the RPC is canceled
+// only when no waiter is left.
+func (g *checkGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (bool, error)) (bool, error) {
+	g.mu.Lock()
+	g.stats.Calls++
+	call, ok := g.calls[key]
+	if ok {
+		call.waiters++
+		g.stats.Coalesced++
+	} else {
+		rpcCtx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &checkCall{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = call
+		go func() {
+			defer cancel()
+			call.result, call.err = fn(rpcCtx)
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			select {
+			case <-call.done:
+			default:
+				call.cancel()
+				g.stats.Canceled++
+				if g.calls[key] == call {
+					delete(g.calls, key)
+				}
+			}
+		}
+		g.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+func (g *checkGroup) snapshot() CoalescingStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
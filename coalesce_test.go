@@ -0,0 +1,128 @@
+package rbns_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// gate holds every Check RPC until released and counts them.
+type gate struct {
+	rpcs     int32
+	canceled int32
+	release  chan struct{}
+}
+
+func (g *gate) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !strings.HasSuffix(method, "/Check") || strings.Contains(method, "Health") {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	atomic.AddInt32(&g.rpcs, 1)
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		atomic.AddInt32(&g.canceled, 1)
+		return ctx.Err()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+func coalescingClient(t *testing.T) (*tests.Server, *rbns.Client, *gate) {
+	srv := tests.NewServer()
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("create:test", "")
+	srv.AddRole("editor", "read:test", "create:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+	g := &gate{release: make(chan struct{})}
+	client := srv.Client(t, rbns.WithCheckCoalescing(), rbns.WithDialOption(grpc.WithUnaryInterceptor(g.intercept)))
+	return srv, client, g
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCheckCoalescing(t *testing.T) {
+	srv, client, g := coalescingClient(t)
+	defer srv.Close()
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			names := []string{"read:test", "create:test"}
+			if i%2 == 1 {
+				names = []string{"create:test", "read:test"}
+			}
+			r, err := client.Check("user1", "default", names...)
+			assert.NoError(t, err)
+			results[i] = r
+		}(i)
+	}
+	waitFor(t, func() bool { return client.CoalescingStats().Calls == n })
+	close(g.release)
+	wg.Wait()
+	for _, r := range results {
+		assert.True(t, r)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&g.rpcs))
+	assert.Equal(t, rbns.CoalescingStats{Calls: n, Coalesced: n - 1}, client.CoalescingStats())
+
+	r, err := client.Check("user1", "default", "read:test")
+	assert.NoError(t, err)
+	assert.True(t, r)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&g.rpcs))
+}
+
+func TestCheckCoalescingCancel(t *testing.T) {
+	srv, client, g := coalescingClient(t)
+	defer srv.Close()
+
+	// The caller that started the RPC leaving does not fail the others.
+	first, cancelFirst := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.CheckContext(first, "user1", "default", "read:test")
+		errs <- err
+	}()
+	waitFor(t, func() bool { return atomic.LoadInt32(&g.rpcs) == 1 })
+	done := make(chan bool, 1)
+	go func() {
+		r, err := client.CheckContext(context.Background(), "user1", "default", "read:test")
+		assert.NoError(t, err)
+		done <- r
+	}()
+	waitFor(t, func() bool { return client.CoalescingStats().Coalesced == 1 })
+	cancelFirst()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	close(g.release)
+	assert.True(t, <-done)
+	assert.Zero(t, atomic.LoadInt32(&g.canceled))
+
+	// The RPC is canceled once every caller has left.
+	g.release = make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.CheckContext(ctx, "user1", "default", "create:test")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	waitFor(t, func() bool { return atomic.LoadInt32(&g.canceled) == 1 })
+	assert.Equal(t, uint64(1), client.CoalescingStats().Canceled)
+}
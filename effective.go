@@ -0,0 +1,210 @@
+package rbns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+)
+
+// EffectivePermission is a permission held by a user together with where it
+// comes from: the roles granting it and whether it is attached directly.
+type EffectivePermission struct {
+	Name        string
+	Description string
+	Roles       []string
+	Direct      bool
+}
+
+// EffectivePermissions is everything a user can do in an organization. It is
+// shared by the cache and must not be modified.
+type EffectivePermissions struct {
+	UserKey          string
+	OrganizationName string
+	Permissions      []EffectivePermission
+	set              *PermissionSet
+}
+
+func (e *EffectivePermissions) Names() []string {
+	names := make([]string, len(e.Permissions))
+	for i, p := range e.Permissions {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func (e *EffectivePermissions) Get(name string) (EffectivePermission, bool) {
+	i := sort.Search(len(e.Permissions), func(i int) bool { return e.Permissions[i].Name >= name })
+	if i < len(e.Permissions) && e.Permissions[i].Name == name {
+		return e.Permissions[i], true
+	}
+	return EffectivePermission{}, false
+}
+
+// Allows reports whether the permissions grant name, honoring wildcard and
+// implication rules.
+func (e *EffectivePermissions) Allows(name string) bool {
+	return e.set.Allows(name)
+}
+
+// EffectivePermissions lists the permissions a user holds in an organization
+// through User.FindByKey, Role.GetPermissions for every assigned role and the
+// permissions attached to the user directly.
+func (c *Client) EffectivePermissions(ctx context.Context, userKey, organizationName string) (*EffectivePermissions, error) {
+	if err := c.readyErr(); err != nil {
+		return nil, err
+	}
+	if c.effective != nil {
+		if e, ok := c.effective.get(userKey, organizationName); ok {
+			return e, nil
+		}
+	}
+	e, err := c.effectivePermissions(c.OutgoingContext(ctx), userKey, organizationName)
+	if err != nil {
+		return nil, err
+	}
+	if c.effective != nil {
+		c.effective.put(e)
+	}
+	return e, nil
+}
+
+// organizationID resolves an organization name through Organization.FindAll.
+func (c *Client) organizationID(ctx context.Context, organizationName string) (string, error) {
+	res, err := proto.NewOrganizationClient(c.con).FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return "", err
+	}
+	for _, o := range res.GetOrganizations() {
+		if o.GetName() == organizationName {
+			return o.GetId(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrOrganizationNotFound, organizationName)
+}
+
+func (c *Client) effectivePermissions(ctx context.Context, userKey, organizationName string) (*EffectivePermissions, error) {
+	organizationID, err := c.organizationID(ctx, organizationName)
+	if err != nil {
+		return nil, err
+	}
+	user, err := proto.NewUserClient(c.con).FindByKey(ctx, &proto.UserKey{Key: userKey, OrganizationId: organizationID})
+	if err != nil {
+		return nil, err
+	}
+	byName := map[string]*EffectivePermission{}
+	grant := func(p *proto.PermissionEntity) *EffectivePermission {
+		e, ok := byName[p.GetName()]
+		if !ok {
+			e = &EffectivePermission{Name: p.GetName(), Description: p.GetDescription()}
+			byName[p.GetName()] = e
+		}
+		return e
+	}
+	for _, p := range user.GetPermissions() {
+		grant(p).Direct = true
+	}
+	roleClient := proto.NewRoleClient(c.con)
+	for _, r := range user.GetRoles() {
+		res, err := roleClient.GetPermissions(ctx, &proto.RoleKey{Id: r.GetId()})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res.GetPermissions() {
+			e := grant(p)
+			e.Roles = append(e.Roles, r.GetName())
+		}
+	}
+	e := &EffectivePermissions{
+		UserKey:          userKey,
+		OrganizationName: organizationName,
+		Permissions:      make([]EffectivePermission, 0, len(byName)),
+		set:              NewPermissionSet(nil),
+	}
+	for name, p := range byName {
+		sort.Strings(p.Roles)
+		e.Permissions = append(e.Permissions, *p)
+		e.set.Add(name)
+	}
+	sort.Slice(e.Permissions, func(i, j int) bool { return e.Permissions[i].Name < e.Permissions[j].Name })
+	return e, nil
+}
+
+type effectiveKey struct {
+	userKey, organizationName string
+}
+
+type effectiveEntry struct {
+	value   *EffectivePermissions
+	expires time.Time
+}
+
+type effectiveCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[effectiveKey]effectiveEntry
+	nextSweep time.Time
+}
+
+func newEffectiveCache(ttl time.Duration) *effectiveCache {
+	return &effectiveCache{ttl: ttl, entries: map[effectiveKey]effectiveEntry{}}
+}
+
+func (c *effectiveCache) get(userKey, organizationName string) (*EffectivePermissions, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := effectiveKey{userKey: userKey, organizationName: organizationName}
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *effectiveCache) put(e *EffectivePermissions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.sweep(now)
+	key := effectiveKey{userKey: e.UserKey, organizationName: e.OrganizationName}
+	c.entries[key] = effectiveEntry{value: e, expires: now.Add(c.ttl)}
+}
+
+// sweep drops the expired entries of subjects that are not asked for again.
+// It runs at most once per ttl so that put stays cheap.
+func (c *effectiveCache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	c.nextSweep = now.Add(c.ttl)
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *effectiveCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[effectiveKey]effectiveEntry{}
+}
+
+// PurgeEffectivePermissions drops every cached EffectivePermissions result.
+func (c *Client) PurgeEffectivePermissions() {
+	if c.effective != nil {
+		c.effective.purge()
+	}
+}
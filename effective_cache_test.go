@@ -0,0 +1,23 @@
+package rbns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveCacheSweep(t *testing.T) {
+	c := newEffectiveCache(time.Minute)
+	c.put(&EffectivePermissions{UserKey: "user1", OrganizationName: "default"})
+	key := effectiveKey{userKey: "user1", organizationName: "default"}
+	c.entries[key] = effectiveEntry{value: c.entries[key].value, expires: time.Now().Add(-time.Second)}
+	c.put(&EffectivePermissions{UserKey: "user2", OrganizationName: "default"})
+	assert.Len(t, c.entries, 2, "swept before ttl")
+
+	c.nextSweep = time.Time{}
+	c.put(&EffectivePermissions{UserKey: "user2", OrganizationName: "default"})
+	assert.Len(t, c.entries, 1)
+	_, ok := c.get("user2", "default")
+	assert.True(t, ok)
+}
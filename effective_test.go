@@ -0,0 +1,53 @@
+package rbns_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	ctx := context.Background()
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "create")
+	srv.AddPermission("read:test", "read")
+	srv.AddPermission("read:*", "read anything")
+	srv.AddRole("editor", "create:test", "read:test")
+	srv.AddRole("viewer", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor", "viewer")
+	srv.AddUserPermission("default", "user1", "read:*")
+	client := srv.Client(t, rbns.WithEffectivePermissionsCache(time.Minute))
+
+	e, err := client.EffectivePermissions(ctx, "user1", "default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create:test", "read:*", "read:test"}, e.Names())
+	p, ok := e.Get("read:test")
+	assert.True(t, ok)
+	assert.Equal(t, rbns.EffectivePermission{Name: "read:test", Description: "read", Roles: []string{"editor", "viewer"}}, p)
+	p, ok = e.Get("read:*")
+	assert.True(t, ok)
+	assert.True(t, p.Direct)
+	assert.Empty(t, p.Roles)
+	assert.True(t, e.Allows("read:other"))
+	assert.False(t, e.Allows("delete:test"))
+
+	srv.AddRole("admin", "create:test")
+	cached, err := client.EffectivePermissions(ctx, "user1", "default")
+	require.NoError(t, err)
+	assert.Same(t, e, cached)
+	client.PurgeEffectivePermissions()
+	fresh, err := client.EffectivePermissions(ctx, "user1", "default")
+	require.NoError(t, err)
+	assert.NotSame(t, e, fresh)
+
+	_, err = client.EffectivePermissions(ctx, "user1", "default2")
+	assert.True(t, errors.Is(err, rbns.ErrOrganizationNotFound))
+}
@@ -0,0 +1,16 @@
+package rbns
+
+// ReadyErr exposes readyErr to the external tests, which cannot be in
+// package rbns as they use the tests server.
+func ReadyErr(c *Client) error {
+	return c.readyErr()
+}
+
+func HealthChecksStopped(c *Client) bool {
+	select {
+	case <-c.readiness.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -4,8 +4,12 @@ go 1.16
 
 require (
 	github.com/gin-gonic/gin v1.7.2
+	github.com/labstack/echo/v4 v4.5.0
 	github.com/n-creativesystem/go-fwncs v0.0.6
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/tools v0.1.5
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.40.0
 	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,181 @@
+package rbns
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+// GrantPath is one way a user holds a permission: through Role, or directly
+// when Role is empty. Permission is the granted name, which differs from the
+// requested one for wildcard or implied grants.
+type GrantPath struct {
+	Role       string
+	Permission string
+}
+
+// PermissionHolder is a user holding a permission and every path granting it.
+type PermissionHolder struct {
+	UserKey string
+	Paths   []GrantPath
+}
+
+type lookupConfig struct {
+	concurrency int
+}
+
+type LookupOption func(conf *lookupConfig)
+
+// WithLookupConcurrency bounds the number of users resolved at once.
+func WithLookupConcurrency(n int) LookupOption {
+	return func(conf *lookupConfig) {
+		if n > 0 {
+			conf.concurrency = n
+		}
+	}
+}
+
+// WhoCan returns the users of an organization holding permissionName, sorted
+// by user key.
+func (c *Client) WhoCan(ctx context.Context, organizationName, permissionName string, opts ...LookupOption) ([]PermissionHolder, error) {
+	var holders []PermissionHolder
+	err := c.StreamWhoCan(ctx, organizationName, permissionName, func(h PermissionHolder) error {
+		holders = append(holders, h)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(holders, func(i, j int) bool { return holders[i].UserKey < holders[j].UserKey })
+	return holders, nil
+}
+
+// StreamWhoCan walks the users returned by Organization.FindById and calls fn
+// for each one holding permissionName as soon as it is resolved. Users are
+// resolved concurrently, so fn sees them in no particular order, but fn is
+// never called concurrently. An error from fn stops the walk and is returned.
+func (c *Client) StreamWhoCan(ctx context.Context, organizationName, permissionName string, fn func(PermissionHolder) error, opts ...LookupOption) error {
+	conf := &lookupConfig{concurrency: 8}
+	for _, opt := range opts {
+		opt(conf)
+	}
+	if err := c.readyErr(); err != nil {
+		return err
+	}
+	ctx = c.OutgoingContext(ctx)
+	organizationID, err := c.organizationID(ctx, organizationName)
+	if err != nil {
+		return err
+	}
+	organization, err := proto.NewOrganizationClient(c.con).FindById(ctx, &proto.OrganizationKey{Id: organizationID})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	roles := &rolePermissions{client: proto.NewRoleClient(c.con), entries: map[string]*rolePermissionsEntry{}}
+	users := make(chan *proto.UserEntity)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	for i := 0; i < conf.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range users {
+				holder, ok, err := holderOf(ctx, u, permissionName, roles)
+				mu.Lock()
+				if err != nil {
+					fail(err)
+				} else if ok && firstErr == nil {
+					if err := fn(holder); err != nil {
+						fail(err)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, u := range organization.GetUsers() {
+		select {
+		case users <- u:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(users)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func holderOf(ctx context.Context, u *proto.UserEntity, permissionName string, roles *rolePermissions) (PermissionHolder, bool, error) {
+	holder := PermissionHolder{UserKey: u.GetKey()}
+	direct := NewPermissionSet(nil)
+	for _, p := range u.GetPermissions() {
+		direct.Add(p.GetName())
+	}
+	if grant := direct.Grant(permissionName); grant != "" {
+		holder.Paths = append(holder.Paths, GrantPath{Permission: grant})
+	}
+	for _, r := range u.GetRoles() {
+		set, err := roles.get(ctx, r.GetId())
+		if err != nil {
+			return holder, false, err
+		}
+		if grant := set.Grant(permissionName); grant != "" {
+			holder.Paths = append(holder.Paths, GrantPath{Role: r.GetName(), Permission: grant})
+		}
+	}
+	return holder, len(holder.Paths) > 0, nil
+}
+
+// rolePermissions memoizes Role.GetPermissions for the duration of a lookup
+// so that each role is fetched once however many users hold it.
+type rolePermissions struct {
+	client  proto.RoleClient
+	mu      sync.Mutex
+	entries map[string]*rolePermissionsEntry
+}
+
+type rolePermissionsEntry struct {
+	once sync.Once
+	set  *PermissionSet
+	err  error
+}
+
+func (r *rolePermissions) get(ctx context.Context, roleID string) (*PermissionSet, error) {
+	r.mu.Lock()
+	e, ok := r.entries[roleID]
+	if !ok {
+		e = &rolePermissionsEntry{}
+		r.entries[roleID] = e
+	}
+	r.mu.Unlock()
+	e.once.Do(func() {
+		res, err := r.client.GetPermissions(ctx, &proto.RoleKey{Id: roleID})
+		if err != nil {
+			e.err = err
+			return
+		}
+		e.set = NewPermissionSet(nil)
+		for _, p := range res.GetPermissions() {
+			e.set.Add(p.GetName())
+		}
+	})
+	return e.set, e.err
+}
@@ -0,0 +1,59 @@
+package rbns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoCan(t *testing.T) {
+	ctx := context.Background()
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("read:*", "")
+	srv.AddRole("editor", "create:test", "read:test")
+	srv.AddRole("viewer", "read:test")
+	srv.AddRole("auditor", "read:*")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor", "viewer")
+	srv.AddUser("default", "user2", "auditor")
+	srv.AddUser("default", "user3")
+	srv.AddUserPermission("default", "user3", "read:test")
+	srv.AddUser("default", "user4")
+	client := srv.Client(t)
+
+	holders, err := client.WhoCan(ctx, "default", "read:test", rbns.WithLookupConcurrency(2))
+	require.NoError(t, err)
+	assert.Equal(t, []rbns.PermissionHolder{
+		{UserKey: "user1", Paths: []rbns.GrantPath{{Role: "editor", Permission: "read:test"}, {Role: "viewer", Permission: "read:test"}}},
+		{UserKey: "user2", Paths: []rbns.GrantPath{{Role: "auditor", Permission: "read:*"}}},
+		{UserKey: "user3", Paths: []rbns.GrantPath{{Permission: "read:test"}}},
+	}, holders)
+
+	holders, err = client.WhoCan(ctx, "default", "create:test")
+	require.NoError(t, err)
+	assert.Len(t, holders, 1)
+
+	for i := 0; i < 50; i++ {
+		srv.AddUser("default", fmt.Sprintf("bulk%d", i), "viewer")
+	}
+	stop := errors.New("stop")
+	seen := 0
+	err = client.StreamWhoCan(ctx, "default", "read:test", func(h rbns.PermissionHolder) error {
+		seen++
+		if seen == 5 {
+			return stop
+		}
+		return nil
+	}, rbns.WithLookupConcurrency(4))
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 5, seen)
+}
@@ -0,0 +1,313 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+// Reconciler makes a server match a manifest through the generated clients.
+type Reconciler struct {
+	client *rbns.Client
+	prune  bool
+	dryRun bool
+	out    io.Writer
+}
+
+type Option func(r *Reconciler)
+
+// WithPrune deletes permissions, roles, organizations and users that the
+// manifest does not declare.
+func WithPrune() Option {
+	return func(r *Reconciler) {
+		r.prune = true
+	}
+}
+
+// WithDryRun computes and reports the plan without applying it.
+func WithDryRun() Option {
+	return func(r *Reconciler) {
+		r.dryRun = true
+	}
+}
+
+// WithOutput writes the plan diff to w before it is applied.
+func WithOutput(w io.Writer) Option {
+	return func(r *Reconciler) {
+		r.out = w
+	}
+}
+
+func NewReconciler(client *rbns.Client, opts ...Option) *Reconciler {
+	r := &Reconciler{client: client}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Plan fetches the current state and computes the changes needed to reach m.
+// Roles are planned with their flattened permission sets so that the
+// server-side Check reflects role inheritance.
+func (r *Reconciler) Plan(ctx context.Context, m *Manifest) (*Plan, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	flat, err := m.Flattened()
+	if err != nil {
+		return nil, err
+	}
+	state, err := Fetch(ctx, r.client)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(state, flat, r.prune), nil
+}
+
+// SyncRoles only makes the permissions of each role declared in m match its
+// flattened permission set, through Role.AddPermissions and
+// Role.DeletePermission. The roles and permissions must already exist on the
+// server; m may omit everything but its roles.
+func (r *Reconciler) SyncRoles(ctx context.Context, m *Manifest) (*Plan, error) {
+	flat, err := m.Flattened()
+	if err != nil {
+		return nil, err
+	}
+	state, err := Fetch(ctx, r.client)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{state: state}
+	for _, c := range Diff(state, &Manifest{Roles: flat.Roles}, false).Changes {
+		switch c.Kind {
+		case KindRole:
+			if c.Op == OpCreate {
+				return nil, fmt.Errorf("manifest: role %q does not exist", c.Name)
+			}
+		case KindRolePermission:
+			if _, ok := state.PermissionIDs[c.Target]; !ok {
+				return nil, fmt.Errorf("manifest: permission %q does not exist", c.Target)
+			}
+			plan.Changes = append(plan.Changes, c)
+		}
+	}
+	return r.run(ctx, plan)
+}
+
+// Apply plans and, unless in dry-run mode, applies the changes in order. The
+// returned plan is valid even when an error stops the apply part way.
+func (r *Reconciler) Apply(ctx context.Context, m *Manifest) (*Plan, error) {
+	plan, err := r.Plan(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return r.run(ctx, plan)
+}
+
+// run reports the plan and applies it unless in dry-run mode.
+func (r *Reconciler) run(ctx context.Context, plan *Plan) (*Plan, error) {
+	if r.out != nil {
+		if _, err := io.WriteString(r.out, plan.String()); err != nil {
+			return plan, err
+		}
+	}
+	if r.dryRun {
+		return plan, nil
+	}
+	return plan, r.apply(ctx, plan)
+}
+
+func (r *Reconciler) apply(ctx context.Context, plan *Plan) error {
+	ctx = r.client.OutgoingContext(ctx)
+	con := r.client.Conn()
+	e := &executor{
+		permissions:   proto.NewPermissionClient(con),
+		roles:         proto.NewRoleClient(con),
+		organizations: proto.NewOrganizationClient(con),
+		users:         proto.NewUserClient(con),
+		state:         plan.state,
+	}
+	for _, c := range plan.Changes {
+		if err := e.exec(ctx, c); err != nil {
+			return fmt.Errorf("manifest: %s: %w", c, err)
+		}
+	}
+	return nil
+}
+
+type executor struct {
+	permissions   proto.PermissionClient
+	roles         proto.RoleClient
+	organizations proto.OrganizationClient
+	users         proto.UserClient
+	state         *State
+}
+
+func (e *executor) id(ids map[string]string, kind Kind, name string) (string, error) {
+	id, ok := ids[name]
+	if !ok {
+		return "", fmt.Errorf("unknown %s %q", kind, name)
+	}
+	return id, nil
+}
+
+func (e *executor) userKey(c Change) (*proto.UserKey, error) {
+	organizationID, err := e.id(e.state.OrganizationIDs, KindOrganization, c.Organization)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.UserKey{Key: c.Name, OrganizationId: organizationID}, nil
+}
+
+func (e *executor) exec(ctx context.Context, c Change) error {
+	switch c.Kind {
+	case KindPermission:
+		return e.execPermission(ctx, c)
+	case KindRole:
+		return e.execRole(ctx, c)
+	case KindRolePermission:
+		roleID, err := e.id(e.state.RoleIDs, KindRole, c.Name)
+		if err != nil {
+			return err
+		}
+		permissionID, err := e.id(e.state.PermissionIDs, KindPermission, c.Target)
+		if err != nil {
+			return err
+		}
+		in := &proto.RoleReleationPermissions{Id: roleID, Permissions: []*proto.PermissionKey{{Id: permissionID}}}
+		if c.Op == OpDelete {
+			_, err = e.roles.DeletePermission(ctx, in)
+		} else {
+			_, err = e.roles.AddPermissions(ctx, in)
+		}
+		return err
+	case KindOrganization:
+		return e.execOrganization(ctx, c)
+	case KindUser:
+		key, err := e.userKey(c)
+		if err != nil {
+			return err
+		}
+		if c.Op == OpDelete {
+			_, err = e.users.Delete(ctx, key)
+		} else {
+			_, err = e.users.Create(ctx, &proto.UserEntity{Key: key.Key, OrganizationId: key.OrganizationId})
+		}
+		return err
+	case KindUserRole:
+		key, err := e.userKey(c)
+		if err != nil {
+			return err
+		}
+		roleID, err := e.id(e.state.RoleIDs, KindRole, c.Target)
+		if err != nil {
+			return err
+		}
+		in := &proto.UserRole{User: key, Roles: []*proto.RoleKey{{Id: roleID}}}
+		if c.Op == OpDelete {
+			_, err = e.users.DeleteRole(ctx, in)
+		} else {
+			_, err = e.users.AddRole(ctx, in)
+		}
+		return err
+	}
+	return fmt.Errorf("unsupported change kind %d", c.Kind)
+}
+
+func (e *executor) execPermission(ctx context.Context, c Change) error {
+	switch c.Op {
+	case OpCreate:
+		res, err := e.permissions.Create(ctx, &proto.PermissionEntities{
+			Permissions: []*proto.PermissionEntity{{Name: c.Name, Description: c.Description}},
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range res.GetPermissions() {
+			e.state.PermissionIDs[p.GetName()] = p.GetId()
+		}
+		return nil
+	case OpUpdate:
+		id, err := e.id(e.state.PermissionIDs, KindPermission, c.Name)
+		if err != nil {
+			return err
+		}
+		_, err = e.permissions.Update(ctx, &proto.PermissionEntity{Id: id, Name: c.Name, Description: c.Description})
+		return err
+	default:
+		id, err := e.id(e.state.PermissionIDs, KindPermission, c.Name)
+		if err != nil {
+			return err
+		}
+		if _, err = e.permissions.Delete(ctx, &proto.PermissionKey{Id: id}); err != nil {
+			return err
+		}
+		delete(e.state.PermissionIDs, c.Name)
+		return nil
+	}
+}
+
+func (e *executor) execRole(ctx context.Context, c Change) error {
+	switch c.Op {
+	case OpCreate:
+		res, err := e.roles.Create(ctx, &proto.RoleEntities{
+			Roles: []*proto.RoleEntity{{Name: c.Name, Description: c.Description}},
+		})
+		if err != nil {
+			return err
+		}
+		for _, r := range res.GetRoles() {
+			e.state.RoleIDs[r.GetName()] = r.GetId()
+		}
+		return nil
+	case OpUpdate:
+		id, err := e.id(e.state.RoleIDs, KindRole, c.Name)
+		if err != nil {
+			return err
+		}
+		_, err = e.roles.Update(ctx, &proto.RoleUpdateEntity{Id: id, Name: c.Name, Description: c.Description})
+		return err
+	default:
+		id, err := e.id(e.state.RoleIDs, KindRole, c.Name)
+		if err != nil {
+			return err
+		}
+		if _, err = e.roles.Delete(ctx, &proto.RoleKey{Id: id}); err != nil {
+			return err
+		}
+		delete(e.state.RoleIDs, c.Name)
+		return nil
+	}
+}
+
+func (e *executor) execOrganization(ctx context.Context, c Change) error {
+	switch c.Op {
+	case OpCreate:
+		res, err := e.organizations.Create(ctx, &proto.OrganizationEntity{Name: c.Name, Description: c.Description})
+		if err != nil {
+			return err
+		}
+		e.state.OrganizationIDs[res.GetName()] = res.GetId()
+		return nil
+	case OpUpdate:
+		id, err := e.id(e.state.OrganizationIDs, KindOrganization, c.Name)
+		if err != nil {
+			return err
+		}
+		_, err = e.organizations.Update(ctx, &proto.OrganizationUpdateEntity{Id: id, Name: c.Name, Description: c.Description})
+		return err
+	default:
+		id, err := e.id(e.state.OrganizationIDs, KindOrganization, c.Name)
+		if err != nil {
+			return err
+		}
+		if _, err = e.organizations.Delete(ctx, &proto.OrganizationKey{Id: id}); err != nil {
+			return err
+		}
+		delete(e.state.OrganizationIDs, c.Name)
+		return nil
+	}
+}
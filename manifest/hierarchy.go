@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleError reports a role that inherits from itself through Path.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("manifest: role inheritance cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// Flatten returns the effective permissions of every role: its own
+// permissions followed by those of the roles it inherits from, transitively
+// and without duplicates.
+func (m *Manifest) Flatten() (map[string][]string, error) {
+	roles := make(map[string]Role, len(m.Roles))
+	for _, r := range m.Roles {
+		roles[r.Name] = r
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := map[string]int{}
+	flat := map[string][]string{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if p == name {
+					start = i
+					break
+				}
+			}
+			return &CycleError{Path: append(append([]string{}, path[start:]...), name)}
+		}
+		role, ok := roles[name]
+		if !ok {
+			return fmt.Errorf("manifest: role %q inherits from undeclared role %q", path[len(path)-1], name)
+		}
+		marks[name] = visiting
+		path = append(path, name)
+		seen := map[string]bool{}
+		var permissions []string
+		add := func(names []string) {
+			for _, p := range names {
+				if !seen[p] {
+					seen[p] = true
+					permissions = append(permissions, p)
+				}
+			}
+		}
+		add(role.Permissions)
+		for _, parent := range role.Inherits {
+			if err := visit(parent, path); err != nil {
+				return err
+			}
+			add(flat[parent])
+		}
+		marks[name] = done
+		flat[name] = permissions
+		return nil
+	}
+	for _, r := range m.Roles {
+		if err := visit(r.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return flat, nil
+}
+
+// Flattened returns a copy of m in which every role lists its effective
+// permissions and no longer inherits.
+func (m *Manifest) Flattened() (*Manifest, error) {
+	flat, err := m.Flatten()
+	if err != nil {
+		return nil, err
+	}
+	res := *m
+	res.Roles = make([]Role, len(m.Roles))
+	for i, r := range m.Roles {
+		res.Roles[i] = Role{Name: r.Name, Description: r.Description, Permissions: flat[r.Name]}
+	}
+	return &res, nil
+}
@@ -0,0 +1,79 @@
+package manifest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatten(t *testing.T) {
+	m := &manifest.Manifest{
+		Roles: []manifest.Role{
+			{Name: "admin", Permissions: []string{"delete:test"}, Inherits: []string{"editor"}},
+			{Name: "editor", Permissions: []string{"create:test", "read:test"}, Inherits: []string{"viewer"}},
+			{Name: "viewer", Permissions: []string{"read:test"}},
+		},
+	}
+	flat, err := m.Flatten()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"delete:test", "create:test", "read:test"}, flat["admin"])
+	assert.Equal(t, []string{"create:test", "read:test"}, flat["editor"])
+	assert.Equal(t, []string{"read:test"}, flat["viewer"])
+
+	m.Roles[2].Inherits = []string{"admin"}
+	_, err = m.Flatten()
+	assert.EqualError(t, err, "manifest: role inheritance cycle: admin -> editor -> viewer -> admin")
+
+	m.Roles[2].Inherits = []string{"owner"}
+	_, err = m.Flatten()
+	assert.EqualError(t, err, `manifest: role "viewer" inherits from undeclared role "owner"`)
+}
+
+func TestSyncRoles(t *testing.T) {
+	ctx := context.Background()
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("admin", "delete:test")
+	srv.AddRole("editor", "create:test")
+	srv.AddRole("viewer", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "admin")
+	client := srv.Client(t)
+
+	m := &manifest.Manifest{
+		Roles: []manifest.Role{
+			{Name: "admin", Permissions: []string{"delete:test"}, Inherits: []string{"editor"}},
+			{Name: "editor", Permissions: []string{"create:test"}, Inherits: []string{"viewer"}},
+			{Name: "viewer", Permissions: []string{"read:test"}},
+		},
+	}
+	plan, err := manifest.NewReconciler(client).SyncRoles(ctx, m)
+	require.NoError(t, err)
+	assert.Equal(t, `+ role admin permission create:test
++ role admin permission read:test
++ role editor permission read:test
+Plan: 3 to create, 0 to update, 0 to delete.
+`, plan.String())
+	r, err := client.Check("user1", "default", "create:test", "read:test", "delete:test")
+	assert.NoError(t, err)
+	assert.True(t, r)
+
+	m.Roles[0].Inherits = nil
+	plan, err = manifest.NewReconciler(client).SyncRoles(ctx, m)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(plan.Changes))
+	r, err = client.Check("user1", "default", "read:test")
+	assert.NoError(t, err)
+	assert.False(t, r)
+
+	m.Roles = append(m.Roles, manifest.Role{Name: "owner"})
+	_, err = manifest.NewReconciler(client).SyncRoles(ctx, m)
+	assert.EqualError(t, err, `manifest: role "owner" does not exist`)
+}
@@ -0,0 +1,139 @@
+// Package manifest declares rbns organizations, roles, permissions and role
+// bindings as a file and reconciles a server against it.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Manifest struct {
+	Permissions   []Permission   `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Roles         []Role         `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Organizations []Organization `json:"organizations,omitempty" yaml:"organizations,omitempty"`
+}
+
+type Permission struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Role lists the permissions granted directly to a role. A role also holds
+// every permission of the roles it inherits from, so admin inheriting editor
+// inheriting viewer makes admin a superset of both.
+type Role struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+	Inherits    []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+}
+
+type Organization struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Users       []User `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+// User binds a user key to roles within its organization. Permissions
+// attached directly to users are not managed because the server has no RPC
+// to change them after creation.
+type User struct {
+	Key   string   `json:"key" yaml:"key"`
+	Roles []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+}
+
+// Load reads a manifest from a .json, .yaml or .yml file.
+func Load(path string) (*Manifest, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseJSON(buf)
+	case ".yaml", ".yml":
+		return ParseYAML(buf)
+	default:
+		return nil, fmt.Errorf("manifest: unsupported file extension %q", filepath.Ext(path))
+	}
+}
+
+func ParseJSON(buf []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(buf, m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return m, m.Validate()
+}
+
+func ParseYAML(buf []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := yaml.UnmarshalStrict(buf, m); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return m, m.Validate()
+}
+
+// Validate reports empty or duplicate names, references to permissions or
+// roles that the manifest does not declare and role inheritance cycles.
+func (m *Manifest) Validate() error {
+	permissions := map[string]bool{}
+	for _, p := range m.Permissions {
+		if p.Name == "" {
+			return fmt.Errorf("manifest: permission name is empty")
+		}
+		if permissions[p.Name] {
+			return fmt.Errorf("manifest: duplicate permission %q", p.Name)
+		}
+		permissions[p.Name] = true
+	}
+	roles := map[string]bool{}
+	for _, r := range m.Roles {
+		if r.Name == "" {
+			return fmt.Errorf("manifest: role name is empty")
+		}
+		if roles[r.Name] {
+			return fmt.Errorf("manifest: duplicate role %q", r.Name)
+		}
+		roles[r.Name] = true
+		for _, p := range r.Permissions {
+			if !permissions[p] {
+				return fmt.Errorf("manifest: role %q references undeclared permission %q", r.Name, p)
+			}
+		}
+	}
+	if _, err := m.Flatten(); err != nil {
+		return err
+	}
+	organizations := map[string]bool{}
+	for _, o := range m.Organizations {
+		if o.Name == "" {
+			return fmt.Errorf("manifest: organization name is empty")
+		}
+		if organizations[o.Name] {
+			return fmt.Errorf("manifest: duplicate organization %q", o.Name)
+		}
+		organizations[o.Name] = true
+		users := map[string]bool{}
+		for _, u := range o.Users {
+			if u.Key == "" {
+				return fmt.Errorf("manifest: organization %q has a user with an empty key", o.Name)
+			}
+			if users[u.Key] {
+				return fmt.Errorf("manifest: duplicate user %q in organization %q", u.Key, o.Name)
+			}
+			users[u.Key] = true
+			for _, r := range u.Roles {
+				if !roles[r] {
+					return fmt.Errorf("manifest: user %q in organization %q references undeclared role %q", u.Key, o.Name, r)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package manifest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `
+permissions:
+  - name: create:test
+    description: create
+  - name: read:test
+  - name: delete:test
+roles:
+  - name: admin
+    permissions: [create:test, read:test, delete:test]
+  - name: viewer
+    permissions: [read:test]
+organizations:
+  - name: default
+    users:
+      - key: user1
+        roles: [admin]
+      - key: user2
+        roles: [viewer]
+`
+
+func TestReconciler(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	client := srv.Client(t)
+	ctx := context.Background()
+
+	m, err := manifest.ParseYAML([]byte(source))
+	require.NoError(t, err)
+
+	cases := tests.Cases{
+		{
+			Name: "dry run",
+			Fn: func(t *testing.T) {
+				var out bytes.Buffer
+				plan, err := manifest.NewReconciler(client, manifest.WithDryRun(), manifest.WithOutput(&out)).Apply(ctx, m)
+				require.NoError(t, err)
+				create, update, del := plan.Summary()
+				assert.Equal(t, 14, create)
+				assert.Zero(t, update)
+				assert.Zero(t, del)
+				assert.Contains(t, out.String(), "+ role admin permission create:test\n")
+				assert.Contains(t, out.String(), "+ organization default user user1 role admin\n")
+				r, err := client.Check("user1", "default", "create:test")
+				assert.NoError(t, err)
+				assert.False(t, r)
+			},
+		},
+		{
+			Name: "apply",
+			Fn: func(t *testing.T) {
+				_, err := manifest.NewReconciler(client).Apply(ctx, m)
+				require.NoError(t, err)
+				r, err := client.Check("user1", "default", "create:test", "delete:test")
+				assert.NoError(t, err)
+				assert.True(t, r)
+				r, err = client.Check("user2", "default", "create:test")
+				assert.NoError(t, err)
+				assert.False(t, r)
+				plan, err := manifest.NewReconciler(client).Plan(ctx, m)
+				require.NoError(t, err)
+				assert.True(t, plan.Empty())
+				assert.Equal(t, "No changes.\n", plan.String())
+			},
+		},
+		{
+			Name: "update without prune",
+			Fn: func(t *testing.T) {
+				srv.AddRole("unmanaged")
+				changed := *m
+				changed.Permissions = append([]manifest.Permission{}, m.Permissions...)
+				changed.Permissions[1].Description = "read"
+				changed.Roles = []manifest.Role{m.Roles[0], {Name: "viewer", Permissions: []string{"read:test", "create:test"}}}
+				changed.Organizations = []manifest.Organization{{Name: "default", Users: []manifest.User{{Key: "user1", Roles: []string{"viewer"}}}}}
+				plan, err := manifest.NewReconciler(client).Apply(ctx, &changed)
+				require.NoError(t, err)
+				assert.Equal(t, `~ permission read:test (description "" => "read")
++ role viewer permission create:test
++ organization default user user1 role viewer
+- organization default user user1 role admin
+Plan: 2 to create, 1 to update, 1 to delete.
+`, plan.String())
+				r, err := client.Check("user1", "default", "delete:test")
+				assert.NoError(t, err)
+				assert.False(t, r)
+				r, err = client.Check("user2", "default", "read:test")
+				assert.NoError(t, err)
+				assert.True(t, r)
+			},
+		},
+		{
+			Name: "prune",
+			Fn: func(t *testing.T) {
+				plan, err := manifest.NewReconciler(client, manifest.WithPrune()).Apply(ctx, m)
+				require.NoError(t, err)
+				assert.Contains(t, plan.String(), "- role unmanaged\n")
+				plan, err = manifest.NewReconciler(client, manifest.WithPrune()).Plan(ctx, m)
+				require.NoError(t, err)
+				assert.True(t, plan.Empty(), plan.String())
+			},
+		},
+	}
+	cases.Run(t)
+}
+
+func TestValidate(t *testing.T) {
+	_, err := manifest.ParseJSON([]byte(`{"roles":[{"name":"admin","permissions":["read:test"]}]}`))
+	assert.EqualError(t, err, `manifest: role "admin" references undeclared permission "read:test"`)
+	_, err = manifest.ParseJSON([]byte(`{"permissions":[{"name":"read:test"},{"name":"read:test"}]}`))
+	assert.EqualError(t, err, `manifest: duplicate permission "read:test"`)
+	_, err = manifest.ParseJSON([]byte(`{"organizations":[{"name":"default","users":[{"key":"user1","roles":["admin"]}]}]}`))
+	assert.EqualError(t, err, `manifest: user "user1" in organization "default" references undeclared role "admin"`)
+}
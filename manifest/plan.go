@@ -0,0 +1,309 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Op int
+
+const (
+	OpCreate Op = iota
+	OpUpdate
+	OpDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpCreate:
+		return "+"
+	case OpUpdate:
+		return "~"
+	case OpDelete:
+		return "-"
+	}
+	return "?"
+}
+
+type Kind int
+
+const (
+	KindPermission Kind = iota
+	KindRole
+	KindRolePermission
+	KindOrganization
+	KindUser
+	KindUserRole
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindPermission:
+		return "permission"
+	case KindRole:
+		return "role"
+	case KindRolePermission:
+		return "role permission"
+	case KindOrganization:
+		return "organization"
+	case KindUser:
+		return "user"
+	case KindUserRole:
+		return "user role"
+	}
+	return "unknown"
+}
+
+// Change is a single step of a plan.
+type Change struct {
+	Op   Op
+	Kind Kind
+	// Organization is set for users and user roles.
+	Organization string
+	// Name is the permission, role or organization name, or the user key.
+	Name string
+	// Target is the permission name of a role permission or the role name of
+	// a user role.
+	Target string
+	// Description is the desired description of a created or updated entity
+	// and Previous the description it replaces.
+	Description string
+	Previous    string
+}
+
+func (c Change) String() string {
+	var b strings.Builder
+	b.WriteString(c.Op.String())
+	b.WriteString(" ")
+	switch c.Kind {
+	case KindPermission, KindRole, KindOrganization:
+		fmt.Fprintf(&b, "%s %s", c.Kind, c.Name)
+	case KindRolePermission:
+		fmt.Fprintf(&b, "role %s permission %s", c.Name, c.Target)
+	case KindUser:
+		fmt.Fprintf(&b, "organization %s user %s", c.Organization, c.Name)
+	case KindUserRole:
+		fmt.Fprintf(&b, "organization %s user %s role %s", c.Organization, c.Name, c.Target)
+	}
+	if c.Op == OpUpdate {
+		fmt.Fprintf(&b, " (description %q => %q)", c.Previous, c.Description)
+	}
+	return b.String()
+}
+
+// phase orders changes so that every reference exists before it is used and
+// is released before it is deleted.
+func (c Change) phase() int {
+	switch c.Op {
+	case OpCreate, OpUpdate:
+		switch c.Kind {
+		case KindPermission:
+			return 0
+		case KindRole:
+			return 1
+		case KindRolePermission:
+			return 2
+		case KindOrganization:
+			return 3
+		case KindUser:
+			return 4
+		case KindUserRole:
+			return 5
+		}
+	case OpDelete:
+		switch c.Kind {
+		case KindUserRole:
+			return 6
+		case KindUser:
+			return 7
+		case KindRolePermission:
+			return 8
+		case KindOrganization:
+			return 9
+		case KindRole:
+			return 10
+		case KindPermission:
+			return 11
+		}
+	}
+	return 12
+}
+
+// Plan is the ordered list of changes that brings a server to a manifest.
+type Plan struct {
+	Changes []Change
+	state   *State
+}
+
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Summary counts the changes by operation.
+func (p *Plan) Summary() (create, update, delete int) {
+	for _, c := range p.Changes {
+		switch c.Op {
+		case OpCreate:
+			create++
+		case OpUpdate:
+			update++
+		case OpDelete:
+			delete++
+		}
+	}
+	return
+}
+
+// String renders the plan as a diff, one change per line, followed by a
+// summary line.
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	create, update, delete := p.Summary()
+	if p.Empty() {
+		b.WriteString("No changes.\n")
+	} else {
+		fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n", create, update, delete)
+	}
+	return b.String()
+}
+
+// Diff computes the plan from state to desired. Entities that exist on the
+// server but are absent from the manifest are only deleted when prune is
+// set; the permissions of a declared role and the roles of a declared user
+// are always made to match the manifest exactly.
+func Diff(state *State, desired *Manifest, prune bool) *Plan {
+	plan := &Plan{state: state}
+	add := func(c Change) {
+		plan.Changes = append(plan.Changes, c)
+	}
+
+	currentPermissions := map[string]Permission{}
+	for _, p := range state.Permissions {
+		currentPermissions[p.Name] = p
+	}
+	desiredPermissions := map[string]bool{}
+	for _, p := range desired.Permissions {
+		desiredPermissions[p.Name] = true
+		if cur, ok := currentPermissions[p.Name]; !ok {
+			add(Change{Op: OpCreate, Kind: KindPermission, Name: p.Name, Description: p.Description})
+		} else if cur.Description != p.Description {
+			add(Change{Op: OpUpdate, Kind: KindPermission, Name: p.Name, Description: p.Description, Previous: cur.Description})
+		}
+	}
+	if prune {
+		for _, p := range state.Permissions {
+			if !desiredPermissions[p.Name] {
+				add(Change{Op: OpDelete, Kind: KindPermission, Name: p.Name})
+			}
+		}
+	}
+
+	currentRoles := map[string]Role{}
+	for _, r := range state.Roles {
+		currentRoles[r.Name] = r
+	}
+	desiredRoles := map[string]bool{}
+	for _, r := range desired.Roles {
+		desiredRoles[r.Name] = true
+		cur, ok := currentRoles[r.Name]
+		if !ok {
+			add(Change{Op: OpCreate, Kind: KindRole, Name: r.Name, Description: r.Description})
+		} else if cur.Description != r.Description {
+			add(Change{Op: OpUpdate, Kind: KindRole, Name: r.Name, Description: r.Description, Previous: cur.Description})
+		}
+		added, removed := diffNames(cur.Permissions, r.Permissions)
+		for _, name := range added {
+			add(Change{Op: OpCreate, Kind: KindRolePermission, Name: r.Name, Target: name})
+		}
+		for _, name := range removed {
+			add(Change{Op: OpDelete, Kind: KindRolePermission, Name: r.Name, Target: name})
+		}
+	}
+	if prune {
+		for _, r := range state.Roles {
+			if !desiredRoles[r.Name] {
+				add(Change{Op: OpDelete, Kind: KindRole, Name: r.Name})
+			}
+		}
+	}
+
+	currentOrganizations := map[string]Organization{}
+	for _, o := range state.Organizations {
+		currentOrganizations[o.Name] = o
+	}
+	desiredOrganizations := map[string]bool{}
+	for _, o := range desired.Organizations {
+		desiredOrganizations[o.Name] = true
+		cur, ok := currentOrganizations[o.Name]
+		if !ok {
+			add(Change{Op: OpCreate, Kind: KindOrganization, Name: o.Name, Description: o.Description})
+		} else if cur.Description != o.Description {
+			add(Change{Op: OpUpdate, Kind: KindOrganization, Name: o.Name, Description: o.Description, Previous: cur.Description})
+		}
+		currentUsers := map[string]User{}
+		for _, u := range cur.Users {
+			currentUsers[u.Key] = u
+		}
+		desiredUsers := map[string]bool{}
+		for _, u := range o.Users {
+			desiredUsers[u.Key] = true
+			curUser, ok := currentUsers[u.Key]
+			if !ok {
+				add(Change{Op: OpCreate, Kind: KindUser, Organization: o.Name, Name: u.Key})
+			}
+			added, removed := diffNames(curUser.Roles, u.Roles)
+			for _, name := range added {
+				add(Change{Op: OpCreate, Kind: KindUserRole, Organization: o.Name, Name: u.Key, Target: name})
+			}
+			for _, name := range removed {
+				add(Change{Op: OpDelete, Kind: KindUserRole, Organization: o.Name, Name: u.Key, Target: name})
+			}
+		}
+		if prune {
+			for _, u := range cur.Users {
+				if !desiredUsers[u.Key] {
+					add(Change{Op: OpDelete, Kind: KindUser, Organization: o.Name, Name: u.Key})
+				}
+			}
+		}
+	}
+	if prune {
+		for _, o := range state.Organizations {
+			if !desiredOrganizations[o.Name] {
+				add(Change{Op: OpDelete, Kind: KindOrganization, Name: o.Name})
+			}
+		}
+	}
+
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].phase() < plan.Changes[j].phase()
+	})
+	return plan
+}
+
+// diffNames returns the names of desired missing from current and the names
+// of current missing from desired, each in their original order.
+func diffNames(current, desired []string) (added, removed []string) {
+	cur := make(map[string]bool, len(current))
+	for _, name := range current {
+		cur[name] = true
+	}
+	want := make(map[string]bool, len(desired))
+	for _, name := range desired {
+		if !want[name] && !cur[name] {
+			added = append(added, name)
+		}
+		want[name] = true
+	}
+	for _, name := range current {
+		if !want[name] {
+			removed = append(removed, name)
+		}
+	}
+	return
+}
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"context"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+// State is the current content of a server expressed as a manifest, along
+// with the server-generated ids of each named entity.
+type State struct {
+	Manifest
+	PermissionIDs   map[string]string
+	RoleIDs         map[string]string
+	OrganizationIDs map[string]string
+}
+
+// Fetch reads the server state through the FindAll RPCs.
+func Fetch(ctx context.Context, client *rbns.Client) (*State, error) {
+	ctx = client.OutgoingContext(ctx)
+	con := client.Conn()
+	state := &State{
+		PermissionIDs:   map[string]string{},
+		RoleIDs:         map[string]string{},
+		OrganizationIDs: map[string]string{},
+	}
+
+	permissions, err := proto.NewPermissionClient(con).FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range permissions.GetPermissions() {
+		state.Permissions = append(state.Permissions, Permission{Name: p.GetName(), Description: p.GetDescription()})
+		state.PermissionIDs[p.GetName()] = p.GetId()
+	}
+
+	roleClient := proto.NewRoleClient(con)
+	roles, err := roleClient.FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles.GetRoles() {
+		rolePermissions, err := roleClient.GetPermissions(ctx, &proto.RoleKey{Id: r.GetId()})
+		if err != nil {
+			return nil, err
+		}
+		role := Role{Name: r.GetName(), Description: r.GetDescription()}
+		for _, p := range rolePermissions.GetPermissions() {
+			role.Permissions = append(role.Permissions, p.GetName())
+		}
+		state.Roles = append(state.Roles, role)
+		state.RoleIDs[r.GetName()] = r.GetId()
+	}
+
+	organizations, err := proto.NewOrganizationClient(con).FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range organizations.GetOrganizations() {
+		organization := Organization{Name: o.GetName(), Description: o.GetDescription()}
+		for _, u := range o.GetUsers() {
+			user := User{Key: u.GetKey()}
+			for _, r := range u.GetRoles() {
+				user.Roles = append(user.Roles, r.GetName())
+			}
+			organization.Users = append(organization.Users, user)
+		}
+		state.Organizations = append(state.Organizations, organization)
+		state.OrganizationIDs[o.GetName()] = o.GetId()
+	}
+	return state, nil
+}
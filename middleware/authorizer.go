@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+var (
+	ErrNoSubject = errors.New("no subject resolved for the request")
+)
+
+// Authorizer answers permission questions for the subject of one request,
+// memoizing every answer for the lifetime of the request.
+type Authorizer struct {
+	client           *rbns.Client
+	userKey          string
+	organizationName string
+
+	mu      sync.Mutex
+	results map[string]bool
+}
+
+func NewAuthorizer(client *rbns.Client, userKey, organizationName string) *Authorizer {
+	return &Authorizer{
+		client:           client,
+		userKey:          userKey,
+		organizationName: organizationName,
+		results:          map[string]bool{},
+	}
+}
+
+func (a *Authorizer) UserKey() string {
+	return a.userKey
+}
+
+func (a *Authorizer) OrganizationName() string {
+	return a.organizationName
+}
+
+// For reports whether a answers for the given client and subject.
+func (a *Authorizer) For(client *rbns.Client, userKey, organizationName string) bool {
+	return a.client == client && a.userKey == userKey && a.organizationName == organizationName
+}
+
+func memoKey(permissionNames []string) string {
+	names := append([]string{}, permissionNames...)
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func (a *Authorizer) lookup(permissionNames []string) (bool, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r, ok := a.results[memoKey(permissionNames)]; ok {
+		return r, true
+	}
+	all := true
+	for _, name := range permissionNames {
+		r, ok := a.results[name]
+		if ok && !r {
+			return false, true
+		}
+		all = all && ok
+	}
+	return true, all
+}
+
+func (a *Authorizer) store(permissionNames []string, r bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results[memoKey(permissionNames)] = r
+	if r || len(permissionNames) == 1 {
+		for _, name := range permissionNames {
+			a.results[name] = r
+		}
+	}
+}
+
+// Can reports whether the subject holds all of permissionNames. Errors are
+// not memoized.
+func (a *Authorizer) Can(permissionNames ...string) (bool, error) {
+	if len(permissionNames) == 0 {
+		return true, nil
+	}
+	if r, ok := a.lookup(permissionNames); ok {
+		return r, nil
+	}
+	r, err := a.client.Check(a.userKey, a.organizationName, permissionNames...)
+	if err != nil {
+		return false, err
+	}
+	a.store(permissionNames, r)
+	return r, nil
+}
+
+// CanAny reports whether the subject holds at least one of permissionNames.
+func (a *Authorizer) CanAny(permissionNames ...string) (bool, error) {
+	for _, name := range permissionNames {
+		r, err := a.Can(name)
+		if err != nil || r {
+			return r, err
+		}
+	}
+	return false, nil
+}
+
+// Require returns ErrForbidden unless the subject holds all of
+// permissionNames.
+func (a *Authorizer) Require(permissionNames ...string) error {
+	r, err := a.Can(permissionNames...)
+	if err != nil {
+		return err
+	}
+	if !r {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Prefetch checks every permission not answered yet, each on its own and
+// concurrently, so that later calls are served from memory.
+func (a *Authorizer) Prefetch(permissionNames ...string) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, 8)
+	for _, name := range permissionNames {
+		if _, ok := a.lookup([]string{name}); ok {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, err := a.Can(name); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(name)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("update:doc", "")
+	srv.AddRole("reader", "read:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	a := middleware.NewAuthorizer(client, "user1", "default")
+	r, err := a.Can("read:doc")
+	require.NoError(t, err)
+	assert.True(t, r)
+	r, err = a.Can("update:doc", "read:doc")
+	require.NoError(t, err)
+	assert.False(t, r)
+	r, err = a.CanAny("update:doc", "read:doc")
+	require.NoError(t, err)
+	assert.True(t, r)
+
+	// Answers are memoized: granting the permission now is not seen.
+	srv.AddUserPermission("default", "user1", "update:doc")
+	r, err = a.Can("read:doc", "update:doc")
+	require.NoError(t, err)
+	assert.False(t, r)
+	assert.ErrorIs(t, a.Require("update:doc", "read:doc"), middleware.ErrForbidden)
+
+	a = middleware.NewAuthorizer(client, "user1", "default")
+	assert.NoError(t, a.Require("update:doc", "read:doc"))
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+// Attributes is a set of named values describing a subject, resource or
+// request. Values may themselves be Attributes or string maps, addressed by
+// dotted paths.
+type Attributes map[string]interface{}
+
+// Input is what conditions are evaluated against.
+type Input struct {
+	Subject  Attributes
+	Resource Attributes
+	Request  Attributes
+}
+
+// Get looks up a dotted path rooted at subject, resource or request, for
+// example "resource.owner" or "request.params.id".
+func (in *Input) Get(path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var cur interface{}
+	switch parts[0] {
+	case "subject":
+		cur = in.Subject
+	case "resource":
+		cur = in.Resource
+	case "request":
+		cur = in.Request
+	default:
+		return nil, false
+	}
+	for _, part := range parts[1:] {
+		var ok bool
+		switch v := cur.(type) {
+		case Attributes:
+			cur, ok = v[part]
+		case map[string]interface{}:
+			cur, ok = v[part]
+		case map[string]string:
+			cur, ok = v[part]
+		default:
+			return nil, false
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+// Condition is a named predicate evaluated in-process after the remote
+// permission check has passed.
+type Condition struct {
+	Name string
+	Fn   func(in *Input) (bool, error)
+}
+
+// ConditionError reports the condition that denied a request. It matches
+// ErrForbidden with errors.Is unless the condition failed with Err, which
+// it wraps instead.
+type ConditionError struct {
+	Condition string
+	Err       error
+}
+
+func (e *ConditionError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("condition %q failed: %s", e.Condition, e.Err)
+	}
+	return fmt.Sprintf("condition %q failed", e.Condition)
+}
+
+func (e *ConditionError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConditionError) Is(target error) bool {
+	return e.Err == nil && target == ErrForbidden
+}
+
+// Equal is satisfied when the attributes at two paths are present and equal,
+// for example Equal("owner", "subject.key", "resource.owner").
+func Equal(name, path, other string) Condition {
+	return Condition{Name: name, Fn: func(in *Input) (bool, error) {
+		a, ok := in.Get(path)
+		if !ok {
+			return false, nil
+		}
+		b, ok := in.Get(other)
+		if !ok {
+			return false, nil
+		}
+		return reflect.DeepEqual(a, b), nil
+	}}
+}
+
+// OneOf is satisfied when the attribute at path equals one of values.
+func OneOf(name, path string, values ...interface{}) Condition {
+	return Condition{Name: name, Fn: func(in *Input) (bool, error) {
+		a, ok := in.Get(path)
+		if !ok {
+			return false, nil
+		}
+		for _, v := range values {
+			if reflect.DeepEqual(a, v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}}
+}
+
+// EvaluateConditions runs the conditions in order and returns a
+// *ConditionError for the first one that is not satisfied.
+func EvaluateConditions(in *Input, conditions ...Condition) error {
+	for _, c := range conditions {
+		ok, err := c.Fn(in)
+		if err != nil {
+			return &ConditionError{Condition: c.Name, Err: err}
+		}
+		if !ok {
+			return &ConditionError{Condition: c.Name}
+		}
+	}
+	return nil
+}
+
+// ConditionCheck runs the remote permission check first and, only when it
+// passes, builds the input and evaluates the conditions in-process.
+func ConditionCheck(client *rbns.Client, userKey, organizationName string, permissionNames []string, input func() (*Input, error), conditions ...Condition) error {
+	if err := PermissionCheck(client, userKey, organizationName, permissionNames...); err != nil {
+		return err
+	}
+	in, err := input()
+	if err != nil {
+		return err
+	}
+	return EvaluateConditions(in, conditions...)
+}
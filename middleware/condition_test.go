@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("update:doc", "")
+	srv.AddRole("editor", "update:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+	srv.AddUser("default", "user2")
+	client := srv.Client(t)
+
+	conditions := []middleware.Condition{
+		middleware.Equal("owner", "subject.key", "resource.owner"),
+		middleware.Equal("department", "subject.department", "resource.department"),
+	}
+	input := func(owner, department string) func() (*middleware.Input, error) {
+		return func() (*middleware.Input, error) {
+			return &middleware.Input{
+				Subject:  middleware.Attributes{"key": "user1", "department": "sales"},
+				Resource: middleware.Attributes{"owner": owner, "department": department},
+			}, nil
+		}
+	}
+
+	cases := tests.Cases{
+		{
+			Name: "allowed",
+			Fn: func(t *testing.T) {
+				err := middleware.ConditionCheck(client, "user1", "default", []string{"update:doc"}, input("user1", "sales"), conditions...)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name: "condition denied",
+			Fn: func(t *testing.T) {
+				err := middleware.ConditionCheck(client, "user1", "default", []string{"update:doc"}, input("user1", "hr"), conditions...)
+				assert.EqualError(t, err, `condition "department" failed`)
+				assert.True(t, errors.Is(err, middleware.ErrForbidden))
+				var ce *middleware.ConditionError
+				if assert.True(t, errors.As(err, &ce)) {
+					assert.Equal(t, "department", ce.Condition)
+				}
+			},
+		},
+		{
+			Name: "condition error",
+			Fn: func(t *testing.T) {
+				errLookup := errors.New("lookup failed")
+				err := middleware.ConditionCheck(client, "user1", "default", []string{"update:doc"}, input("user1", "sales"), middleware.Condition{
+					Name: "archived",
+					Fn:   func(*middleware.Input) (bool, error) { return false, errLookup },
+				})
+				assert.EqualError(t, err, `condition "archived" failed: lookup failed`)
+				assert.False(t, errors.Is(err, middleware.ErrForbidden))
+				assert.True(t, errors.Is(err, errLookup))
+			},
+		},
+		{
+			Name: "permission denied before conditions",
+			Fn: func(t *testing.T) {
+				called := false
+				err := middleware.ConditionCheck(client, "user2", "default", []string{"update:doc"}, func() (*middleware.Input, error) {
+					called = true
+					return nil, nil
+				}, conditions...)
+				assert.Equal(t, middleware.ErrForbidden, err)
+				assert.False(t, called)
+			},
+		},
+	}
+	cases.Run(t)
+}
+
+func TestInputGet(t *testing.T) {
+	in := &middleware.Input{
+		Request: middleware.Attributes{"params": map[string]string{"id": "42"}},
+	}
+	v, ok := in.Get("request.params.id")
+	assert.True(t, ok)
+	assert.Equal(t, "42", v)
+	_, ok = in.Get("request.params.missing")
+	assert.False(t, ok)
+	_, ok = in.Get("other.key")
+	assert.False(t, ok)
+	assert.NoError(t, middleware.EvaluateConditions(in, middleware.OneOf("id", "request.params.id", "41", "42")))
+}
@@ -0,0 +1,44 @@
+package echo
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+// clientWithOptions connects a client for the request. The returned func
+// closes it and must be called once the request is served.
+func clientWithOptions(c echo.Context, opts ...rbns.Option) (func(), error) {
+	ctx := c.Request().Context()
+	client, err := rbns.Connection(ctx, opts...)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	c.Set(rbns.ClientKey, client)
+	return func() { _ = client.Close() }, nil
+}
+
+// ClientWithOptions connects a client for each request and closes it once
+// the request is served. Prefer Client with a shared client.
+func ClientWithOptions(opts ...rbns.Option) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			closeClient, err := clientWithOptions(c, opts...)
+			if err != nil {
+				return err
+			}
+			defer closeClient()
+			return next(c)
+		}
+	}
+}
+
+func Client(client *rbns.Client) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(rbns.ClientKey, client)
+			return next(c)
+		}
+	}
+}
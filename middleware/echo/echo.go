@@ -0,0 +1,67 @@
+package echo
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+var (
+	ErrNoSDK = errors.New("client sdk is empty")
+)
+
+type GetUserOrganization func(c echo.Context) (userKey string, organizationName string, err error)
+
+func echoPermissionCheck(c echo.Context, fn GetUserOrganization, permissionNames ...string) error {
+	client, ok := c.Get(rbns.ClientKey).(*rbns.Client)
+	if !ok || client == nil {
+		return middleware.NewFailure(middleware.FailureNoClient, ErrNoSDK)
+	}
+	userKey, organizationName, err := fn(c)
+	if err != nil {
+		return middleware.NewFailure(middleware.FailureUnauthenticated, err)
+	}
+	return middleware.PermissionCheck(client, userKey, organizationName, permissionNames...)
+}
+
+// permissionCheck reports failures to eh with the status of
+// middleware.Classify.
+func permissionCheck(fn GetUserOrganization, eh ErrorHandler, permissionNames []string, opts []rbns.Option) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if opts != nil {
+				closeClient, err := clientWithOptions(c, opts...)
+				if err != nil {
+					return err
+				}
+				defer closeClient()
+			}
+			if err := echoPermissionCheck(c, fn, permissionNames...); err != nil {
+				return eh(c, middleware.Classify(err))
+			}
+			return next(c)
+		}
+	}
+}
+
+func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) echo.MiddlewareFunc {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, append([]rbns.Option{}, opts...))
+}
+
+// PermissionCheckWithErrorHandler is PermissionCheck with eh reporting
+// rejected requests.
+func PermissionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, permissionNames ...string) echo.MiddlewareFunc {
+	return permissionCheck(fn, eh, permissionNames, nil)
+}
+
+func PermissionCheck(fn GetUserOrganization, permissionNames ...string) echo.MiddlewareFunc {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, nil)
+}
+
+// TypedPermissionCheck is PermissionCheck taking the constants generated by
+// rbns-gen.
+func TypedPermissionCheck(fn GetUserOrganization, permissions ...rbns.Permission) echo.MiddlewareFunc {
+	return PermissionCheck(fn, rbns.PermissionNames(permissions...)...)
+}
@@ -0,0 +1,232 @@
+package echo_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsEcho "github.com/n-creativesystem/go-rbns/middleware/echo"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func getUser(c echo.Context) (userKey, organizationName string, err error) {
+	userKey = c.Request().Header.Get("X-User")
+	organizationName = "default"
+	err = nil
+	return
+}
+
+func getUserNoExistsOrganization(c echo.Context) (userKey, organizationName string, err error) {
+	userKey = c.Request().Header.Get("X-User")
+	organizationName = "default2"
+	err = nil
+	return
+}
+
+func middlewarePermission(permissions ...string) echo.MiddlewareFunc {
+	return rbnsEcho.PermissionCheck(getUser, permissions...)
+}
+
+func middlewarePermissionNoExistsOrganization(permissions ...string) echo.MiddlewareFunc {
+	return rbnsEcho.PermissionCheck(getUserNoExistsOrganization, permissions...)
+}
+
+func request(method, url, userKey string) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	req.Header.Set("X-User", userKey)
+	return req
+}
+
+func TestEchoWAF(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("writer", "create:test", "read:test")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "writer")
+	srv.AddUser("default", "user2", "reader")
+
+	router := echo.New()
+	router.Use(rbnsEcho.ClientWithOptions(rbns.WithHost(srv.Addr()), rbns.WithDialOption(srv.DialOptions()...)))
+	users := router.Group("/api")
+	{
+		users.POST("/users", func(c echo.Context) error {
+			userKey := c.Request().Header.Get("X-User")
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": userKey})
+		}, middlewarePermission("create:test"))
+		users.GET("/users/:id", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": c.Param("id")})
+		}, middlewarePermission("read:test"))
+		users.DELETE("/users/:id", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": c.Param("id")})
+		}, middlewarePermissionNoExistsOrganization("delete:test"))
+	}
+	noUsers := router.Group("/api")
+	{
+		noUsers.POST("/no-users", func(c echo.Context) error {
+			userKey := c.Request().Header.Get("X-User")
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": userKey})
+		}, middlewarePermissionNoExistsOrganization("create:test"))
+		noUsers.GET("/no-users/:id", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": c.Param("id")})
+		}, middlewarePermissionNoExistsOrganization("read:test"))
+		noUsers.DELETE("/no-users/:id", func(c echo.Context) error {
+			return c.JSON(http.StatusOK, map[string]interface{}{"user": c.Param("id")})
+		}, middlewarePermissionNoExistsOrganization("delete:test"))
+	}
+
+	ok := func(method, url, userKey, body string) func(t *testing.T) {
+		return func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request(method, url, userKey))
+			assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+			assert.Equal(t, body, strings.TrimSpace(w.Body.String()))
+		}
+	}
+	ng := func(method, url, userKey string) func(t *testing.T) {
+		return func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request(method, url, userKey))
+			assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+			assert.Equal(t, `{"message":"Forbidden"}`, strings.TrimSpace(w.Body.String()))
+		}
+	}
+
+	cases := tests.Cases{
+		{
+			Name: "organization ok",
+			Fn: func(t *testing.T) {
+				childCases := tests.Cases{
+					{
+						Name: "user1",
+						Fn: func(t *testing.T) {
+							childCases := tests.Cases{
+								{Name: "create ok", Fn: ok(http.MethodPost, "/api/users", "user1", `{"user":"user1"}`)},
+								{Name: "read ok", Fn: ok(http.MethodGet, "/api/users/user2", "user1", `{"user":"user2"}`)},
+								{Name: "delete ng", Fn: ng(http.MethodDelete, "/api/users/user2", "user1")},
+							}
+							childCases.Run(t)
+						},
+					},
+					{
+						Name: "user2",
+						Fn: func(t *testing.T) {
+							childCases := tests.Cases{
+								{Name: "create ng", Fn: ng(http.MethodPost, "/api/users", "user2")},
+								{Name: "read ok", Fn: ok(http.MethodGet, "/api/users/user1", "user2", `{"user":"user1"}`)},
+								{Name: "delete ng", Fn: ng(http.MethodDelete, "/api/users/user1", "user2")},
+							}
+							childCases.Run(t)
+						},
+					},
+					{
+						Name: "user3 no exists",
+						Fn: func(t *testing.T) {
+							childCases := tests.Cases{
+								{Name: "create ng", Fn: ng(http.MethodPost, "/api/users", "user3")},
+								{Name: "read ng", Fn: ng(http.MethodGet, "/api/users/user1", "user3")},
+								{Name: "delete ng", Fn: ng(http.MethodDelete, "/api/users/user1", "user3")},
+							}
+							childCases.Run(t)
+						},
+					},
+				}
+				childCases.Run(t)
+			},
+		},
+		{
+			Name: "organization no exists",
+			Fn: func(t *testing.T) {
+				for _, user := range []string{"user1", "user2", "user3"} {
+					childCases := tests.Cases{
+						{Name: user + " create ng", Fn: ng(http.MethodPost, "/api/no-users", user)},
+						{Name: user + " read ng", Fn: ng(http.MethodGet, "/api/no-users/user1", user)},
+						{Name: user + " delete ng", Fn: ng(http.MethodDelete, "/api/no-users/user1", user)},
+					}
+					childCases.Run(t)
+				}
+			},
+		},
+	}
+	cases.Run(t)
+}
+
+func TestEchoNoClient(t *testing.T) {
+	router := echo.New()
+	router.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middlewarePermission("read:test"))
+	router.GET("/nil", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, rbnsEcho.Client(nil), middlewarePermission("read:test"))
+	for _, url := range []string{"/", "/nil"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, url, "user1"))
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode, url)
+	}
+}
+
+func TestEchoErrorHandler(t *testing.T) {
+	srv := tests.NewServer()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	noUser := func(c echo.Context) (string, string, error) { return "", "", errors.New("no user") }
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	router := echo.New()
+	router.Use(rbnsEcho.Client(client))
+	router.GET("/anonymous", ok, rbnsEcho.PermissionCheck(noUser, "read:test"))
+	router.GET("/problem", ok, rbnsEcho.PermissionCheckWithErrorHandler(getUser, rbnsEcho.ProblemJSONErrorHandler, "read:test"))
+	router.GET("/json", ok, rbnsEcho.PermissionCheckWithErrorHandler(getUser, rbnsEcho.JSONErrorHandler, "read:test"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/anonymous", "user1"))
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/problem", "user2"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	assert.Equal(t, middleware.ProblemContentType, w.Header().Get(echo.HeaderContentType))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Forbidden","status":403,"detail":"Forbidden","instance":"/problem","code":"forbidden"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/json", "user2"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	assert.JSONEq(t, `{"status":403,"code":"forbidden","message":"Forbidden"}`, w.Body.String())
+
+	srv.Close()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/json", "user1"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+}
+
+func TestEchoClient(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	router := echo.New()
+	router.Use(rbnsEcho.Client(client))
+	router.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, middlewarePermission("read:test"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/", "user1"))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
@@ -0,0 +1,33 @@
+package echo
+
+import (
+	"encoding/json"
+
+	"github.com/labstack/echo/v4"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// ErrorHandler returns the error for a request the middleware rejected, or
+// writes the response itself and returns nil.
+type ErrorHandler func(c echo.Context, f *middleware.Failure) error
+
+// DefaultErrorHandler returns an *echo.HTTPError with the failure's status,
+// keeping the cause as its internal error, so that echo's HTTPErrorHandler
+// renders it.
+func DefaultErrorHandler(c echo.Context, f *middleware.Failure) error {
+	return echo.NewHTTPError(f.Status()).SetInternal(f.Err)
+}
+
+// ProblemJSONErrorHandler writes an RFC 7807 application/problem+json body.
+func ProblemJSONErrorHandler(c echo.Context, f *middleware.Failure) error {
+	body, err := json.Marshal(middleware.NewProblem(f, c.Request().URL.Path))
+	if err != nil {
+		return c.NoContent(f.Status())
+	}
+	return c.Blob(f.Status(), middleware.ProblemContentType, body)
+}
+
+// JSONErrorHandler writes a middleware.ErrorBody.
+func JSONErrorHandler(c echo.Context, f *middleware.Failure) error {
+	return c.JSON(f.Status(), middleware.NewErrorBody(f))
+}
@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError is an invalid permission expression.
+type SyntaxError struct {
+	Expression string
+	Offset     int
+	Msg        string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("permission expression %q: %s at offset %d", e.Expression, e.Msg, e.Offset)
+}
+
+type exprOp int
+
+const (
+	exprName exprOp = iota
+	exprNot
+	exprAnd
+	exprOr
+)
+
+type exprNode struct {
+	op       exprOp
+	name     string
+	children []*exprNode
+}
+
+// Expression is a compiled boolean combination of permission names using
+// &&, ||, ! and parentheses, for example
+//
+//	create:doc && (approve:doc || role-admin:org)
+//
+// && binds tighter than ||.
+type Expression struct {
+	source      string
+	root        *exprNode
+	permissions []string
+}
+
+func ParseExpression(s string) (*Expression, error) {
+	p := &exprParser{src: s}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(s) {
+		return nil, p.errorf("unexpected %q", s[p.pos:p.pos+1])
+	}
+	e := &Expression{source: s, root: root}
+	seen := map[string]bool{}
+	var collect func(n *exprNode)
+	collect = func(n *exprNode) {
+		if n.op == exprName && !seen[n.name] {
+			seen[n.name] = true
+			e.permissions = append(e.permissions, n.name)
+		}
+		for _, c := range n.children {
+			collect(c)
+		}
+	}
+	collect(root)
+	return e, nil
+}
+
+func MustParseExpression(s string) *Expression {
+	e, err := ParseExpression(s)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Permissions lists the permission names referenced by the expression.
+func (e *Expression) Permissions() []string {
+	return e.permissions
+}
+
+// Evaluate resolves the expression for the subject of a. Evaluation short
+// circuits, and the plain names under an && are checked together in one
+// call, whose answer is memoized for each of them when it is true.
+func (e *Expression) Evaluate(a *Authorizer) (bool, error) {
+	return evaluate(e.root, a)
+}
+
+func evaluate(n *exprNode, a *Authorizer) (bool, error) {
+	switch n.op {
+	case exprName:
+		return a.Can(n.name)
+	case exprNot:
+		r, err := evaluate(n.children[0], a)
+		return !r, err
+	case exprAnd:
+		var names []string
+		for _, c := range n.children {
+			if c.op == exprName {
+				names = append(names, c.name)
+			}
+		}
+		if len(names) > 0 {
+			if r, err := a.Can(names...); err != nil || !r {
+				return false, err
+			}
+		}
+		for _, c := range n.children {
+			if c.op == exprName {
+				continue
+			}
+			if r, err := evaluate(c, a); err != nil || !r {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		// Answer from memory first so that known grants avoid remote checks.
+		for _, c := range n.children {
+			if c.op == exprName {
+				if r, ok := a.lookup([]string{c.name}); ok && r {
+					return true, nil
+				}
+			}
+		}
+		for _, c := range n.children {
+			if r, err := evaluate(c, a); err != nil || r {
+				return r, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Check returns ErrForbidden unless the expression holds for the subject.
+func (e *Expression) Check(a *Authorizer) error {
+	r, err := e.Evaluate(a)
+	if err != nil {
+		return err
+	}
+	if !r {
+		return ErrForbidden
+	}
+	return nil
+}
+
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Expression: p.src, Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *exprParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (*exprNode, error) {
+	return p.parseBinary(exprOr, "||", p.parseAnd)
+}
+
+func (p *exprParser) parseAnd() (*exprNode, error) {
+	return p.parseBinary(exprAnd, "&&", p.parseUnary)
+}
+
+func (p *exprParser) parseBinary(op exprOp, token string, operand func() (*exprNode, error)) (*exprNode, error) {
+	n, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(token) {
+		return n, nil
+	}
+	parent := &exprNode{op: op, children: []*exprNode{n}}
+	for {
+		n, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		// Flatten a && (b && c) into one node.
+		if n.op == op {
+			parent.children = append(parent.children, n.children...)
+		} else {
+			parent.children = append(parent.children, n)
+		}
+		if !p.consume(token) {
+			return parent, nil
+		}
+	}
+}
+
+func isNameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || strings.IndexByte(":-_.*/", b) >= 0
+}
+
+func (p *exprParser) parseUnary() (*exprNode, error) {
+	if p.consume("!") {
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{op: exprNot, children: []*exprNode{n}}, nil
+	}
+	if p.consume("(") {
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("missing ')'")
+		}
+		return n, nil
+	}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos == len(p.src) {
+			return nil, p.errorf("unexpected end of expression")
+		}
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	return &exprNode{op: exprName, name: p.src[start:p.pos]}, nil
+}
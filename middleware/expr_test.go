@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestParseExpression(t *testing.T) {
+	e, err := middleware.ParseExpression("create:doc && (approve:doc || role-admin:org) && !archive:*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create:doc", "approve:doc", "role-admin:org", "archive:*"}, e.Permissions())
+
+	invalid := map[string]string{
+		"":                   `permission expression "": unexpected end of expression at offset 0`,
+		"a &&":               `permission expression "a &&": unexpected end of expression at offset 4`,
+		"(a || b":            `permission expression "(a || b": missing ')' at offset 7`,
+		"a b":                `permission expression "a b": unexpected "b" at offset 2`,
+		"a & b":              `permission expression "a & b": unexpected "&" at offset 2`,
+		"create:doc || $foo": `permission expression "create:doc || $foo": unexpected "$" at offset 14`,
+	}
+	for s, msg := range invalid {
+		_, err := middleware.ParseExpression(s)
+		var syntaxErr *middleware.SyntaxError
+		if assert.ErrorAs(t, err, &syntaxErr, s) {
+			assert.EqualError(t, err, msg)
+		}
+	}
+	assert.Panics(t, func() { middleware.MustParseExpression("a ||") })
+}
+
+func TestExpressionEvaluate(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	for _, name := range []string{"create:doc", "approve:doc", "role-admin:org", "archive:doc"} {
+		srv.AddPermission(name, "")
+	}
+	srv.AddRole("author", "create:doc")
+	srv.AddRole("approver", "create:doc", "approve:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "author")
+	srv.AddUser("default", "user2", "approver")
+	var checks int32
+	count := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if strings.HasSuffix(method, "/Check") {
+			atomic.AddInt32(&checks, 1)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client := srv.Client(t, rbns.WithDialOption(grpc.WithUnaryInterceptor(count)))
+
+	cases := []struct {
+		expression string
+		userKey    string
+		result     bool
+		checks     int32
+	}{
+		{"create:doc && approve:doc", "user2", true, 1},
+		{"create:doc && approve:doc", "user1", false, 1},
+		{"create:doc && (approve:doc || role-admin:org)", "user2", true, 2},
+		{"create:doc && (approve:doc || role-admin:org)", "user1", false, 3},
+		{"role-admin:org || create:doc && !archive:doc", "user1", true, 3},
+		{"!create:doc || approve:doc", "user1", false, 2},
+		{"approve:doc || create:doc && approve:doc", "user1", false, 1},
+	}
+	for _, c := range cases {
+		atomic.StoreInt32(&checks, 0)
+		e := middleware.MustParseExpression(c.expression)
+		r, err := e.Evaluate(middleware.NewAuthorizer(client, c.userKey, "default"))
+		require.NoError(t, err, c.expression)
+		assert.Equal(t, c.result, r, "%s as %s", c.expression, c.userKey)
+		assert.Equal(t, c.checks, atomic.LoadInt32(&checks), "%s as %s", c.expression, c.userKey)
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FailureKind says why a request was not let through.
+type FailureKind int
+
+const (
+	// FailureInternal is any unexpected error, including the permission
+	// server rejecting the client itself.
+	FailureInternal FailureKind = iota
+	// FailureUnauthenticated is a request whose subject could not be read.
+	FailureUnauthenticated
+	// FailureForbidden is a denied permission check, condition or policy.
+	FailureForbidden
+	// FailureNoClient is a middleware running without an SDK client.
+	FailureNoClient
+	// FailureUnavailable is a permission server that could not be reached.
+	FailureUnavailable
+)
+
+var failureKinds = map[FailureKind]struct {
+	code   string
+	status int
+}{
+	FailureInternal:        {"internal", http.StatusInternalServerError},
+	FailureUnauthenticated: {"unauthenticated", http.StatusUnauthorized},
+	FailureForbidden:       {"forbidden", http.StatusForbidden},
+	FailureNoClient:        {"no_client", http.StatusInternalServerError},
+	FailureUnavailable:     {"unavailable", http.StatusServiceUnavailable},
+}
+
+func (k FailureKind) String() string {
+	return failureKinds[k].code
+}
+
+// Status is the HTTP status the kind maps to.
+func (k FailureKind) Status() int {
+	return failureKinds[k].status
+}
+
+// Failure is a classified middleware error.
+type Failure struct {
+	Kind FailureKind
+	Err  error
+}
+
+func NewFailure(kind FailureKind, err error) *Failure {
+	return &Failure{Kind: kind, Err: err}
+}
+
+func (f *Failure) Error() string {
+	return f.Kind.String() + ": " + f.Err.Error()
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+func (f *Failure) Status() int {
+	return f.Kind.Status()
+}
+
+// Classify returns err as a *Failure. Errors already classified by the
+// middleware keep their kind; otherwise denials are forbidden, token and
+// missing subject errors unauthenticated, and unreachable servers
+// unavailable.
+func Classify(err error) *Failure {
+	var f *Failure
+	if errors.As(err, &f) {
+		return f
+	}
+	switch {
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrNoPolicy):
+		return NewFailure(FailureForbidden, err)
+	case errors.Is(err, ErrNoValue), errors.Is(err, ErrInvalidToken):
+		return NewFailure(FailureUnauthenticated, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, rbns.ErrNotReady):
+		return NewFailure(FailureUnavailable, err)
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded:
+			return NewFailure(FailureUnavailable, err)
+		}
+	}
+	return NewFailure(FailureInternal, err)
+}
+
+// Problem is an RFC 7807 problem details body. Detail is only filled for
+// client errors so that server internals are not exposed.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+const ProblemContentType = "application/problem+json"
+
+func NewProblem(f *Failure, instance string) *Problem {
+	p := &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(f.Status()),
+		Status:   f.Status(),
+		Instance: instance,
+		Code:     f.Kind.String(),
+	}
+	if f.Status() < http.StatusInternalServerError {
+		p.Detail = f.Err.Error()
+	}
+	return p
+}
+
+// ErrorBody is the plain JSON error body.
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewErrorBody(f *Failure) *ErrorBody {
+	return &ErrorBody{Status: f.Status(), Code: f.Kind.String(), Message: http.StatusText(f.Status())}
+}
@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err    error
+		kind   middleware.FailureKind
+		status int
+	}{
+		{middleware.ErrForbidden, middleware.FailureForbidden, http.StatusForbidden},
+		{&middleware.ConditionError{Condition: "owner"}, middleware.FailureForbidden, http.StatusForbidden},
+		{fmt.Errorf("%w: GET /", middleware.ErrNoPolicy), middleware.FailureForbidden, http.StatusForbidden},
+		{fmt.Errorf("user key: %w", middleware.ErrNoValue), middleware.FailureUnauthenticated, http.StatusUnauthorized},
+		{middleware.NewFailure(middleware.FailureNoClient, errors.New("no client")), middleware.FailureNoClient, http.StatusInternalServerError},
+		{status.Error(codes.Unavailable, "connection refused"), middleware.FailureUnavailable, http.StatusServiceUnavailable},
+		{context.DeadlineExceeded, middleware.FailureUnavailable, http.StatusServiceUnavailable},
+		{fmt.Errorf("%w: Status unhealthy: NOT_SERVING", rbns.ErrNotReady), middleware.FailureUnavailable, http.StatusServiceUnavailable},
+		{status.Error(codes.Unauthenticated, "bad api key"), middleware.FailureInternal, http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		f := middleware.Classify(c.err)
+		assert.Equal(t, c.kind, f.Kind, c.err.Error())
+		assert.Equal(t, c.status, f.Status(), c.err.Error())
+		assert.True(t, errors.Is(f, c.err) || f == c.err, c.err.Error())
+	}
+
+	p := middleware.NewProblem(middleware.Classify(middleware.ErrForbidden), "/docs/1")
+	assert.Equal(t, &middleware.Problem{Type: "about:blank", Title: "Forbidden", Status: 403, Detail: "Forbidden", Instance: "/docs/1", Code: "forbidden"}, p)
+	p = middleware.NewProblem(middleware.Classify(errors.New("secret")), "/docs/1")
+	assert.Empty(t, p.Detail)
+}
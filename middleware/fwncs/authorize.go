@@ -0,0 +1,89 @@
+package fwncs
+
+import (
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+const authorizerKey = "rbns-authorizer"
+
+// resolveAuthorizer returns the authorizer of the subject fn extracts. The
+// authorizer stored on the request is reused when it is for the same client
+// and subject, so that stacked checks share its answers; the first one
+// resolved is stored for Can and Require. fn always runs, so stacked checks
+// with different extractors each check their own subject.
+func resolveAuthorizer(c fwncs.Context, fn GetUserOrganization) (*middleware.Authorizer, error) {
+	client, err := getClient(c)
+	if err != nil {
+		return nil, err
+	}
+	userKey, organizationName, err := getUserOrganization(c, fn)
+	if err != nil {
+		return nil, err
+	}
+	stored, ok := Authorizer(c)
+	if ok && stored.For(client, userKey, organizationName) {
+		return stored, nil
+	}
+	a := middleware.NewAuthorizer(client, userKey, organizationName)
+	if !ok {
+		c.Set(authorizerKey, a)
+	}
+	return a, nil
+}
+
+// Authorizer returns the authorizer stored by Subject or by any of the
+// permission check middleware.
+func Authorizer(c fwncs.Context) (*middleware.Authorizer, bool) {
+	a, ok := c.Get(authorizerKey).(*middleware.Authorizer)
+	return a, ok
+}
+
+// Subject resolves the client and subject of the request without checking
+// anything, for handlers that only use Can and Require.
+func Subject(fn GetUserOrganization) fwncs.HandlerFunc {
+	return func(c fwncs.Context) {
+		if _, err := resolveAuthorizer(c, fn); err != nil && reject(c, DefaultErrorHandler, err, nil) {
+			return
+		}
+		c.Next()
+	}
+}
+
+func authorize(c fwncs.Context, permissionNames []string) (bool, error) {
+	a, ok := Authorizer(c)
+	if !ok {
+		return false, middleware.NewFailure(middleware.FailureUnauthenticated, middleware.ErrNoSubject)
+	}
+	return a.Can(permissionNames...)
+}
+
+// Can reports whether the subject holds all of permissionNames. Errors are
+// recorded with c.Error and reported as not allowed.
+func Can(c fwncs.Context, permissionNames ...string) bool {
+	r, err := authorize(c, permissionNames)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	return r
+}
+
+// Require aborts the request through DefaultErrorHandler and returns the
+// error unless the subject holds all of permissionNames.
+func Require(c fwncs.Context, permissionNames ...string) error {
+	return RequireWithErrorHandler(c, DefaultErrorHandler, permissionNames...)
+}
+
+// RequireWithErrorHandler is Require with eh writing the response. On a
+// route in shadow mode the failure is recorded and nil returned.
+func RequireWithErrorHandler(c fwncs.Context, eh ErrorHandler, permissionNames ...string) error {
+	r, err := authorize(c, permissionNames)
+	if err == nil && !r {
+		err = middleware.ErrForbidden
+	}
+	if err != nil && reject(c, eh, err, permissionNames) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package fwncs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n-creativesystem/go-fwncs"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackedSubjects(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("call:api", "")
+	srv.AddRole("reader", "read:doc", "call:api")
+	srv.AddRole("caller", "call:api")
+	srv.AddOrganization("default")
+	srv.AddOrganization("services")
+	srv.AddUser("default", "user1", "reader")
+	srv.AddUser("services", "svc1", "caller")
+	srv.AddUser("services", "svc2")
+	client := srv.Client(t)
+
+	getService := func(c fwncs.Context) (string, string, error) {
+		return c.Header().Get("X-Service"), "services", nil
+	}
+	router := fwncs.New()
+	router.Use(rbnsFwncs.Client(client), rbnsFwncs.PermissionCheck(getUser, "read:doc"))
+	router.GET("/docs", rbnsFwncs.PermissionCheck(getService, "call:api"), func(c fwncs.Context) {
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+
+	for service, status := range map[string]int{"svc1": http.StatusNoContent, "svc2": http.StatusForbidden} {
+		req := request(http.MethodGet, "/docs", "user1")
+		req.Header.Set("X-Service", service)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Result().StatusCode, service)
+	}
+}
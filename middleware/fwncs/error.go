@@ -0,0 +1,38 @@
+package fwncs
+
+import (
+	"encoding/json"
+
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// ErrorHandler writes the response for a request the middleware rejected.
+// It must stop the handler chain.
+type ErrorHandler func(c fwncs.Context, f *middleware.Failure)
+
+// DefaultErrorHandler writes fwncs' standard error body with the failure's
+// status.
+func DefaultErrorHandler(c fwncs.Context, f *middleware.Failure) {
+	c.AbortWithStatusAndErrorMessage(f.Status(), f.Err)
+}
+
+// ProblemJSONErrorHandler writes an RFC 7807 application/problem+json body.
+func ProblemJSONErrorHandler(c fwncs.Context, f *middleware.Failure) {
+	c.Error(f.Err)
+	c.Skip()
+	body, err := json.Marshal(middleware.NewProblem(f, c.Request().URL.Path))
+	if err != nil {
+		c.AbortWithStatus(f.Status())
+		return
+	}
+	c.SetHeader("Content-Type", middleware.ProblemContentType)
+	c.Writer().WriteHeader(f.Status())
+	_, _ = c.Writer().Write(body)
+}
+
+// JSONErrorHandler writes a middleware.ErrorBody.
+func JSONErrorHandler(c fwncs.Context, f *middleware.Failure) {
+	c.Error(f.Err)
+	c.AbortWithStatusAndMessage(f.Status(), middleware.NewErrorBody(f))
+}
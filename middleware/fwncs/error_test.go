@@ -0,0 +1,43 @@
+package fwncs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n-creativesystem/go-fwncs"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1")
+	client := srv.Client(t)
+
+	ok := func(c fwncs.Context) { c.JSON(http.StatusOK, map[string]string{}) }
+	router := fwncs.New()
+	router.GET("/no-client", rbnsFwncs.PermissionCheck(getUser, "read:test"), ok)
+	router.GET("/problem", rbnsFwncs.Client(client), rbnsFwncs.PermissionCheckWithErrorHandler(getUser, rbnsFwncs.ProblemJSONErrorHandler, "read:test"), ok)
+	router.GET("/json", rbnsFwncs.Client(client), rbnsFwncs.PermissionCheckWithErrorHandler(getUser, rbnsFwncs.JSONErrorHandler, "read:test"), ok)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/no-client", "user1"))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	assert.JSONEq(t, `{"status":500,"message":"error","message_describe":"client sdk is empty"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/problem", "user1"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Forbidden","status":403,"detail":"Forbidden","instance":"/problem","code":"forbidden"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/json", "user1"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	assert.JSONEq(t, `{"status":403,"code":"forbidden","message":"Forbidden"}`, w.Body.String())
+}
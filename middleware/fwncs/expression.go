@@ -0,0 +1,28 @@
+package fwncs
+
+import (
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// ExpressionCheck requires a boolean permission expression such as
+// "create:doc && (approve:doc || role-admin:org)". The expression is
+// compiled here and a syntax error panics, so it surfaces at startup.
+func ExpressionCheck(fn GetUserOrganization, expression string) fwncs.HandlerFunc {
+	return ExpressionCheckWithErrorHandler(fn, DefaultErrorHandler, middleware.MustParseExpression(expression))
+}
+
+// ExpressionCheckWithErrorHandler is ExpressionCheck for a compiled
+// expression with eh writing the response of rejected requests.
+func ExpressionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, e *middleware.Expression) fwncs.HandlerFunc {
+	return func(c fwncs.Context) {
+		a, err := resolveAuthorizer(c, fn)
+		if err == nil {
+			err = e.Check(a)
+		}
+		if err != nil && reject(c, eh, err, e.Permissions()) {
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,46 +1,71 @@
 package fwncs
 
 import (
-	"net/http"
+	"errors"
 
 	"github.com/n-creativesystem/go-fwncs"
 	rbns "github.com/n-creativesystem/go-rbns"
 	"github.com/n-creativesystem/go-rbns/middleware"
 )
 
+var (
+	ErrNoSDK = errors.New("client sdk is empty")
+)
+
 type GetUserOrganization func(c fwncs.Context) (userKey string, organizationName string, err error)
 
-func fwncsPermissionCheck(c fwncs.Context, fn GetUserOrganization, permissionNames ...string) error {
-	var client *rbns.Client
-	if v, ok := c.Get(rbns.ClientKey).(*rbns.Client); ok {
-		client = v
+func getClient(c fwncs.Context) (*rbns.Client, error) {
+	client, ok := c.Get(rbns.ClientKey).(*rbns.Client)
+	if !ok || client == nil {
+		return nil, middleware.NewFailure(middleware.FailureNoClient, ErrNoSDK)
 	}
+	return client, nil
+}
+
+func getUserOrganization(c fwncs.Context, fn GetUserOrganization) (string, string, error) {
 	userKey, organizationName, err := fn(c)
+	if err != nil {
+		return "", "", middleware.NewFailure(middleware.FailureUnauthenticated, err)
+	}
+	return userKey, organizationName, nil
+}
+
+func fwncsPermissionCheck(c fwncs.Context, fn GetUserOrganization, permissionNames ...string) error {
+	a, err := resolveAuthorizer(c, fn)
 	if err != nil {
 		return err
 	}
-	return middleware.PermissionCheck(client, userKey, organizationName, permissionNames...)
+	return a.Require(permissionNames...)
 }
 
-func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) fwncs.HandlerFunc {
+func permissionCheck(fn GetUserOrganization, eh ErrorHandler, permissionNames []string, opts []rbns.Option) fwncs.HandlerFunc {
 	return func(c fwncs.Context) {
-		if !clientWithOptions(c, opts...) {
+		if opts != nil && !clientWithOptions(c, opts...) {
 			return
 		}
-		if err := fwncsPermissionCheck(c, fn, permissionNames...); err != nil {
-			c.AbortWithStatusAndErrorMessage(http.StatusForbidden, err)
-		} else {
-			c.Next()
+		if err := fwncsPermissionCheck(c, fn, permissionNames...); err != nil && reject(c, eh, err, permissionNames) {
+			return
 		}
+		c.Next()
 	}
 }
 
+func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) fwncs.HandlerFunc {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, append([]rbns.Option{}, opts...))
+}
+
+// PermissionCheckWithErrorHandler is PermissionCheck with eh writing the
+// response of rejected requests.
+func PermissionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, permissionNames ...string) fwncs.HandlerFunc {
+	return permissionCheck(fn, eh, permissionNames, nil)
+}
+
 func PermissionCheck(fn GetUserOrganization, permissionNames ...string) fwncs.HandlerFunc {
-	return func(c fwncs.Context) {
-		if err := fwncsPermissionCheck(c, fn, permissionNames...); err != nil {
-			c.AbortWithStatusAndErrorMessage(http.StatusForbidden, err)
-		} else {
-			c.Next()
-		}
-	}
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, nil)
+}
+
+// TypedPermissionCheck is PermissionCheck taking the constants generated by
+// rbns-gen.
+func TypedPermissionCheck(fn GetUserOrganization, permissions ...rbns.Permission) fwncs.HandlerFunc {
+	return PermissionCheck(fn, rbns.PermissionNames(permissions...)...)
 }
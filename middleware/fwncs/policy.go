@@ -0,0 +1,36 @@
+package fwncs
+
+import (
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// PolicyCheck protects every route with a single middleware driven by p,
+// typically installed with router.Use after Client.
+func PolicyCheck(p *middleware.Policy, fn GetUserOrganization) fwncs.HandlerFunc {
+	return PolicyCheckWithErrorHandler(p, fn, DefaultErrorHandler)
+}
+
+// PolicyCheckWithErrorHandler is PolicyCheck with eh writing the response of
+// rejected requests.
+func PolicyCheckWithErrorHandler(p *middleware.Policy, fn GetUserOrganization, eh ErrorHandler) fwncs.HandlerFunc {
+	return func(c fwncs.Context) {
+		err := p.Check(c.Request().Method, c.Request().URL.Path, func() (*middleware.Authorizer, error) {
+			return resolveAuthorizer(c, fn)
+		})
+		if err != nil && reject(c, eh, err, policyPermissions(p, c)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidatePolicy reports the routes that p has no rule for. fwncs does not
+// expose its route table, so the routes are passed in as registered.
+func ValidatePolicy(p *middleware.Policy, routes ...fwncs.RouterInfo) error {
+	rs := make([]middleware.Route, len(routes))
+	for i, r := range routes {
+		rs[i] = middleware.Route{Method: r.Method, Path: r.Path}
+	}
+	return p.Validate(rs)
+}
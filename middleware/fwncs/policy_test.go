@@ -0,0 +1,71 @@
+package fwncs_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	p := middleware.MustNewPolicy([]middleware.Rule{
+		{Method: http.MethodGet, Path: "/api/users/:id", Permissions: []string{"read:test"}},
+	}, middleware.WithDefaultDeny())
+
+	ok := func(c fwncs.Context) { c.JSON(http.StatusOK, map[string]string{"user": c.Param("id")}) }
+	router := fwncs.New()
+	router.Use(rbnsFwncs.Client(client), rbnsFwncs.PolicyCheck(p, getUser))
+	router.GET("/api/users/:id", ok)
+	router.DELETE("/api/users/:id", ok)
+
+	assert.EqualError(t, rbnsFwncs.ValidatePolicy(p,
+		fwncs.RouterInfo{Method: http.MethodGet, Path: "/api/users/:id"},
+		fwncs.RouterInfo{Method: http.MethodDelete, Path: "/api/users/:id"},
+	), "policy: 1 routes have no rule: DELETE /api/users/:id")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/api/users/2", "user1"))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/api/users/2", "user2"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodDelete, "/api/users/2", "user1"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+}
+
+func TestPolicyCheckWithoutClient(t *testing.T) {
+	p := middleware.MustNewPolicy([]middleware.Rule{
+		{Method: http.MethodGet, Path: "/health", Public: true},
+		{Method: http.MethodGet, Path: "/api/users/:id", Permissions: []string{"read:test"}},
+	})
+	ok := func(c fwncs.Context) { c.AbortWithStatus(http.StatusNoContent) }
+	router := fwncs.New()
+	router.Use(rbnsFwncs.PolicyCheck(p, getUser))
+	router.GET("/health", ok)
+	router.GET("/metrics", ok)
+	router.GET("/api/users/:id", ok)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/health", "user1"))
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/metrics", "user1"))
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/api/users/2", "user1"))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+}
@@ -0,0 +1,48 @@
+package fwncs
+
+import (
+	"github.com/n-creativesystem/go-fwncs"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+const shadowKey = "rbns-shadow"
+
+// ShadowMode makes the permission checks of the following handlers run in
+// shadow mode on the routes s enables: failures are recorded and the
+// request goes through. fwncs does not expose the matched route pattern, so
+// routes are matched by request path.
+func ShadowMode(s *middleware.Shadow) fwncs.HandlerFunc {
+	return func(c fwncs.Context) {
+		c.Set(shadowKey, s)
+		c.Next()
+	}
+}
+
+// reject classifies err and either records it when the route is in shadow
+// mode, returning false, or writes the response through eh.
+func reject(c fwncs.Context, eh ErrorHandler, err error, permissionNames []string) bool {
+	f := middleware.Classify(err)
+	method, path := c.Request().Method, c.Request().URL.Path
+	if s, ok := c.Get(shadowKey).(*middleware.Shadow); ok && s.Enabled(method, path) {
+		d := middleware.ShadowDenial{
+			Method:      method,
+			Route:       path,
+			Permissions: permissionNames,
+			Failure:     f,
+		}
+		if a, ok := Authorizer(c); ok {
+			d.UserKey, d.OrganizationName = a.UserKey(), a.OrganizationName()
+		}
+		s.Record(d)
+		return false
+	}
+	eh(c, f)
+	return true
+}
+
+func policyPermissions(p *middleware.Policy, c fwncs.Context) []string {
+	if rule, ok := p.Match(c.Request().Method, c.Request().URL.Path); ok {
+		return rule.Permissions
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package gin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanRequire(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("publish:doc", "")
+	srv.AddRole("reader", "read:doc")
+	srv.AddRole("publisher", "read:doc", "publish:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	srv.AddUser("default", "user2", "publisher")
+	client := srv.Client(t)
+
+	drafts := map[string]bool{"1": true, "2": false}
+	router := gin.New()
+	router.Use(rbnsGin.Client(client))
+	router.GET("/docs/:id", rbnsGin.PermissionCheck(getUser, "read:doc"), func(c *gin.Context) {
+		if drafts[c.Param("id")] {
+			if err := rbnsGin.Require(c, "publish:doc"); err != nil {
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"publish": rbnsGin.Can(c, "publish:doc")})
+	})
+	router.GET("/anonymous", func(c *gin.Context) {
+		if err := rbnsGin.Require(c, "read:doc"); err != nil {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/subject", rbnsGin.Subject(getUser), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"read": rbnsGin.Can(c, "read:doc")})
+	})
+
+	cases := []struct {
+		url, user string
+		status    int
+		body      string
+	}{
+		{"/docs/2", "user1", http.StatusOK, `{"publish":false}`},
+		{"/docs/2", "user2", http.StatusOK, `{"publish":true}`},
+		{"/docs/1", "user1", http.StatusForbidden, ""},
+		{"/docs/1", "user2", http.StatusOK, `{"publish":true}`},
+		{"/anonymous", "user1", http.StatusUnauthorized, ""},
+		{"/subject", "user1", http.StatusOK, `{"read":true}`},
+		{"/subject", "user3", http.StatusOK, `{"read":false}`},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, c.url, c.user))
+		assert.Equal(t, c.status, w.Result().StatusCode, c.url)
+		assert.Equal(t, c.body, w.Body.String(), c.url)
+	}
+}
+
+func TestStackedSubjects(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("call:api", "")
+	srv.AddRole("reader", "read:doc", "call:api")
+	srv.AddRole("caller", "call:api")
+	srv.AddOrganization("default")
+	srv.AddOrganization("services")
+	srv.AddUser("default", "user1", "reader")
+	srv.AddUser("services", "svc1", "caller")
+	srv.AddUser("services", "svc2")
+	client := srv.Client(t)
+
+	getService := func(c *gin.Context) (string, string, error) {
+		return c.GetHeader("X-Service"), "services", nil
+	}
+	router := gin.New()
+	router.Use(rbnsGin.Client(client), rbnsGin.PermissionCheck(getUser, "read:doc"))
+	router.GET("/docs", rbnsGin.PermissionCheck(getService, "call:api"), func(c *gin.Context) {
+		a, _ := rbnsGin.Authorizer(c)
+		c.String(http.StatusOK, a.UserKey())
+	})
+
+	for service, status := range map[string]int{"svc1": http.StatusOK, "svc2": http.StatusForbidden} {
+		req := request(http.MethodGet, "/docs", "user1")
+		req.Header.Set("X-Service", service)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Result().StatusCode, service)
+		if status == http.StatusOK {
+			assert.Equal(t, "user1", w.Body.String())
+		}
+	}
+}
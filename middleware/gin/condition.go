@@ -0,0 +1,65 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// AttributeLoader returns subject or resource attributes for the request.
+type AttributeLoader func(c *gin.Context) (middleware.Attributes, error)
+
+func requestAttributes(c *gin.Context) middleware.Attributes {
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return middleware.Attributes{
+		"method": c.Request.Method,
+		"path":   c.Request.URL.Path,
+		"route":  c.FullPath(),
+		"params": params,
+	}
+}
+
+func ginConditionCheck(c *gin.Context, fn GetUserOrganization, subject, resource AttributeLoader, permissionNames []string, conditions []middleware.Condition) error {
+	a, err := resolveAuthorizer(c, fn)
+	if err != nil {
+		return err
+	}
+	if err := a.Require(permissionNames...); err != nil {
+		return err
+	}
+	in := &middleware.Input{
+		Subject:  middleware.Attributes{"key": a.UserKey(), "organization": a.OrganizationName()},
+		Resource: middleware.Attributes{},
+		Request:  requestAttributes(c),
+	}
+	if subject != nil {
+		attrs, err := subject(c)
+		if err != nil {
+			return err
+		}
+		for k, v := range attrs {
+			in.Subject[k] = v
+		}
+	}
+	if resource != nil {
+		if in.Resource, err = resource(c); err != nil {
+			return err
+		}
+	}
+	return middleware.EvaluateConditions(in, conditions...)
+}
+
+// ConditionCheck requires permissionNames through the remote check and then
+// evaluates conditions in-process. The subject attributes always hold "key"
+// and "organization"; the request attributes hold "method", "path", "route"
+// and "params". Either loader may be nil.
+func ConditionCheck(fn GetUserOrganization, subject, resource AttributeLoader, permissionNames []string, conditions ...middleware.Condition) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := ginConditionCheck(c, fn, subject, resource, permissionNames, conditions); err != nil && reject(c, DefaultErrorHandler, err, permissionNames) {
+			return
+		}
+		c.Next()
+	}
+}
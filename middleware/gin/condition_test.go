@@ -0,0 +1,68 @@
+package gin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("update:doc", "")
+	srv.AddRole("editor", "update:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+	srv.AddUser("default", "user2", "editor")
+	client := srv.Client(t)
+
+	owners := map[string]string{"1": "user1", "2": "user2"}
+	loadDocument := func(c *gin.Context) (middleware.Attributes, error) {
+		return middleware.Attributes{"owner": owners[c.Param("id")]}, nil
+	}
+
+	router := gin.New()
+	router.Use(rbnsGin.Client(client))
+	router.PUT("/docs/:id", rbnsGin.ConditionCheck(getUser, nil, loadDocument, []string{"update:doc"},
+		middleware.Equal("owner", "subject.key", "resource.owner"),
+	), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodPut, "/docs/1", "user1"))
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodPut, "/docs/1", "user2"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	errLookup := errors.New("lookup failed")
+	router.PUT("/archive/:id", rbnsGin.ConditionCheck(getUser, nil, nil, []string{"update:doc"},
+		middleware.Condition{Name: "archivable", Fn: func(*middleware.Input) (bool, error) {
+			return false, errLookup
+		}},
+	), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.PUT("/missing/:id", rbnsGin.ConditionCheck(getUser, nil, func(*gin.Context) (middleware.Attributes, error) {
+		return nil, errLookup
+	}, []string{"update:doc"}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodPut, "/archive/1", "user1"))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodPut, "/missing/1", "user1"))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+}
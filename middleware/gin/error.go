@@ -0,0 +1,36 @@
+package gin
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// ErrorHandler writes the response for a request the middleware rejected.
+// It must abort the context.
+type ErrorHandler func(c *gin.Context, f *middleware.Failure)
+
+// DefaultErrorHandler aborts with the failure's status and an empty body,
+// recording the cause in c.Errors.
+func DefaultErrorHandler(c *gin.Context, f *middleware.Failure) {
+	_ = c.AbortWithError(f.Status(), f.Err)
+}
+
+// ProblemJSONErrorHandler writes an RFC 7807 application/problem+json body.
+func ProblemJSONErrorHandler(c *gin.Context, f *middleware.Failure) {
+	_ = c.Error(f.Err)
+	c.Abort()
+	body, err := json.Marshal(middleware.NewProblem(f, c.Request.URL.Path))
+	if err != nil {
+		c.Status(f.Status())
+		return
+	}
+	c.Data(f.Status(), middleware.ProblemContentType, body)
+}
+
+// JSONErrorHandler writes a middleware.ErrorBody.
+func JSONErrorHandler(c *gin.Context, f *middleware.Failure) {
+	_ = c.Error(f.Err)
+	c.AbortWithStatusJSON(f.Status(), middleware.NewErrorBody(f))
+}
@@ -0,0 +1,65 @@
+package gin_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	rbns "github.com/n-creativesystem/go-rbns"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	srv := tests.NewServer()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	getUserOrError := func(c *gin.Context) (string, string, error) {
+		if c.GetHeader("X-User") == "" {
+			return "", "", errors.New("no user")
+		}
+		return getUser(c)
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.GET("/no-client", rbnsGin.PermissionCheckWithErrorHandler(getUser, rbnsGin.JSONErrorHandler, "read:test"), ok)
+	api := router.Group("/api", rbnsGin.Client(client))
+	api.GET("/default", rbnsGin.PermissionCheck(getUserOrError, "read:test"), ok)
+	api.GET("/typed", rbnsGin.TypedPermissionCheck(getUserOrError, rbns.Permission("read:test")), ok)
+	api.GET("/json", rbnsGin.PermissionCheckWithErrorHandler(getUserOrError, rbnsGin.JSONErrorHandler, "read:test"), ok)
+	api.GET("/problem", rbnsGin.PermissionCheckWithErrorHandler(getUserOrError, rbnsGin.ProblemJSONErrorHandler, "read:test"), ok)
+
+	type expect struct {
+		url, user   string
+		status      int
+		contentType string
+		body        string
+	}
+	run := func(e expect) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, e.url, e.user))
+		assert.Equal(t, e.status, w.Result().StatusCode, e.url)
+		assert.Equal(t, e.contentType, w.Header().Get("Content-Type"), e.url)
+		assert.Equal(t, e.body, w.Body.String(), e.url)
+	}
+	run(expect{"/api/default", "user1", http.StatusNoContent, "", ""})
+	run(expect{"/api/default", "user2", http.StatusForbidden, "", ""})
+	run(expect{"/api/typed", "user1", http.StatusNoContent, "", ""})
+	run(expect{"/api/typed", "user2", http.StatusForbidden, "", ""})
+	run(expect{"/api/default", "", http.StatusUnauthorized, "", ""})
+	run(expect{"/api/json", "user2", http.StatusForbidden, "application/json; charset=utf-8", `{"status":403,"code":"forbidden","message":"Forbidden"}`})
+	run(expect{"/no-client", "user1", http.StatusInternalServerError, "application/json; charset=utf-8", `{"status":500,"code":"no_client","message":"Internal Server Error"}`})
+	run(expect{"/api/problem", "", http.StatusUnauthorized, "application/problem+json",
+		`{"type":"about:blank","title":"Unauthorized","status":401,"detail":"no user","instance":"/api/problem","code":"unauthenticated"}`})
+
+	srv.Close()
+	run(expect{"/api/problem", "user1", http.StatusServiceUnavailable, "application/problem+json",
+		`{"type":"about:blank","title":"Service Unavailable","status":503,"instance":"/api/problem","code":"unavailable"}`})
+}
@@ -0,0 +1,38 @@
+package gin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:doc", "")
+	srv.AddPermission("approve:doc", "")
+	srv.AddPermission("role-admin:org", "")
+	srv.AddRole("author", "create:doc")
+	srv.AddRole("approver", "create:doc", "approve:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "author")
+	srv.AddUser("default", "user2", "approver")
+	client := srv.Client(t)
+
+	router := gin.New()
+	router.Use(rbnsGin.Client(client))
+	router.POST("/docs", rbnsGin.ExpressionCheck(getUser, "create:doc && (approve:doc || role-admin:org)"), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	for user, status := range map[string]int{"user1": http.StatusForbidden, "user2": http.StatusCreated, "": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodPost, "/docs", user))
+		assert.Equal(t, status, w.Result().StatusCode, user)
+	}
+	assert.Panics(t, func() { rbnsGin.ExpressionCheck(getUser, "create:doc ||") })
+}
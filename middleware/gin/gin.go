@@ -2,7 +2,6 @@ package gin
 
 import (
 	"errors"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
 	rbns "github.com/n-creativesystem/go-rbns"
@@ -15,40 +14,64 @@ var (
 
 type GetUserOrganization func(c *gin.Context) (userKey string, organizationName string, err error)
 
-func ginPermissionCheck(c *gin.Context, fn GetUserOrganization, permissionNames ...string) error {
+func getClient(c *gin.Context) (*rbns.Client, error) {
 	var client *rbns.Client
 	if v, ok := c.Get(rbns.ClientKey); ok {
 		client, ok = v.(*rbns.Client)
 		if !ok {
-			return EreNoSDK
+			return nil, middleware.NewFailure(middleware.FailureNoClient, EreNoSDK)
 		}
 	}
+	if client == nil {
+		return nil, middleware.NewFailure(middleware.FailureNoClient, EreNoSDK)
+	}
+	return client, nil
+}
+
+func getUserOrganization(c *gin.Context, fn GetUserOrganization) (string, string, error) {
 	userKey, organizationName, err := fn(c)
+	if err != nil {
+		return "", "", middleware.NewFailure(middleware.FailureUnauthenticated, err)
+	}
+	return userKey, organizationName, nil
+}
+
+func ginPermissionCheck(c *gin.Context, fn GetUserOrganization, permissionNames ...string) error {
+	a, err := resolveAuthorizer(c, fn)
 	if err != nil {
 		return err
 	}
-	return middleware.PermissionCheck(client, userKey, organizationName, permissionNames...)
+	return a.Require(permissionNames...)
 }
 
-func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) gin.HandlerFunc {
+func permissionCheck(fn GetUserOrganization, eh ErrorHandler, permissionNames []string, opts []rbns.Option) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !clientWithOptions(c, opts...) {
+		if opts != nil && !clientWithOptions(c, opts...) {
 			return
 		}
-		if err := ginPermissionCheck(c, fn, permissionNames...); err != nil {
-			c.AbortWithError(http.StatusForbidden, err)
-		} else {
-			c.Next()
+		if err := ginPermissionCheck(c, fn, permissionNames...); err != nil && reject(c, eh, err, permissionNames) {
+			return
 		}
+		c.Next()
 	}
 }
 
+func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) gin.HandlerFunc {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, append([]rbns.Option{}, opts...))
+}
+
+// PermissionCheckWithErrorHandler is PermissionCheck with eh writing the
+// response of rejected requests.
+func PermissionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, permissionNames ...string) gin.HandlerFunc {
+	return permissionCheck(fn, eh, permissionNames, nil)
+}
+
 func PermissionCheck(fn GetUserOrganization, permissionNames ...string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		if err := ginPermissionCheck(c, fn, permissionNames...); err != nil {
-			c.AbortWithError(http.StatusForbidden, err)
-		} else {
-			c.Next()
-		}
-	}
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, nil)
+}
+
+// TypedPermissionCheck is PermissionCheck taking the constants generated by
+// rbns-gen.
+func TypedPermissionCheck(fn GetUserOrganization, permissions ...rbns.Permission) gin.HandlerFunc {
+	return PermissionCheck(fn, rbns.PermissionNames(permissions...)...)
 }
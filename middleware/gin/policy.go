@@ -0,0 +1,37 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// PolicyCheck protects every route with a single middleware driven by p,
+// typically installed with router.Use after Client.
+func PolicyCheck(p *middleware.Policy, fn GetUserOrganization) gin.HandlerFunc {
+	return PolicyCheckWithErrorHandler(p, fn, DefaultErrorHandler)
+}
+
+// PolicyCheckWithErrorHandler is PolicyCheck with eh writing the response of
+// rejected requests.
+func PolicyCheckWithErrorHandler(p *middleware.Policy, fn GetUserOrganization, eh ErrorHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := p.Check(c.Request.Method, c.Request.URL.Path, func() (*middleware.Authorizer, error) {
+			return resolveAuthorizer(c, fn)
+		})
+		if err != nil && reject(c, eh, err, policyPermissions(p, c)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidatePolicy reports the routes registered on router that p has no rule
+// for. Call it once all routes are registered.
+func ValidatePolicy(router *gin.Engine, p *middleware.Policy) error {
+	routes := router.Routes()
+	rs := make([]middleware.Route, len(routes))
+	for i, r := range routes {
+		rs[i] = middleware.Route{Method: r.Method, Path: r.Path}
+	}
+	return p.Validate(rs)
+}
@@ -0,0 +1,123 @@
+package gin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	p := middleware.MustNewPolicy([]middleware.Rule{
+		{Method: http.MethodGet, Path: "/health", Public: true},
+		{Method: http.MethodPost, Path: "/api/users", Permissions: []string{"create:test"}},
+		{Method: http.MethodGet, Path: "/api/users/:id", Permissions: []string{"read:test"}},
+	}, middleware.WithDefaultDeny())
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.Use(rbnsGin.Client(client), rbnsGin.PolicyCheck(p, getUser))
+	router.GET("/health", ok)
+	router.POST("/api/users", ok)
+	router.GET("/api/users/:id", func(c *gin.Context) {
+		if rbnsGin.Can(c, "read:test") {
+			c.Status(http.StatusNoContent)
+		}
+	})
+	router.DELETE("/api/users/:id", ok)
+
+	assert.EqualError(t, rbnsGin.ValidatePolicy(router, p), "policy: 1 routes have no rule: DELETE /api/users/:id")
+
+	cases := []struct {
+		method, url, user string
+		status            int
+	}{
+		{http.MethodGet, "/health", "", http.StatusNoContent},
+		{http.MethodGet, "/api/users/2", "user1", http.StatusNoContent},
+		{http.MethodPost, "/api/users", "user1", http.StatusForbidden},
+		{http.MethodDelete, "/api/users/2", "user1", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(c.method, c.url, c.user))
+		assert.Equal(t, c.status, w.Result().StatusCode, c.method+" "+c.url)
+	}
+
+	// The handler's Can is answered by the authorizer the policy asked.
+	checks := srv.Checks()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/api/users/2", "user1"))
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	assert.Equal(t, checks+1, srv.Checks())
+}
+
+func TestPolicyCheckWithoutClient(t *testing.T) {
+	p := middleware.MustNewPolicy([]middleware.Rule{
+		{Method: http.MethodGet, Path: "/health", Public: true},
+		{Method: http.MethodGet, Path: "/api/users/:id", Permissions: []string{"read:test"}},
+	})
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.Use(rbnsGin.PolicyCheck(p, getUser))
+	router.GET("/health", ok)
+	router.GET("/metrics", ok)
+	router.GET("/api/users/:id", ok)
+
+	cases := []struct {
+		url    string
+		status int
+	}{
+		{"/health", http.StatusNoContent},
+		{"/metrics", http.StatusNoContent},
+		{"/api/users/2", http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, c.url, "user1"))
+		assert.Equal(t, c.status, w.Result().StatusCode, c.url)
+	}
+}
+
+func TestPolicyCheckWildcard(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("admin:all", "")
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	p := middleware.MustNewPolicy([]middleware.Rule{
+		{Path: "/admin/*path", Permissions: []string{"admin:all"}},
+	})
+	router := gin.New()
+	router.Use(rbnsGin.Client(client), rbnsGin.PolicyCheck(p, getUser))
+	router.GET("/admin/*path", func(c *gin.Context) { c.String(http.StatusOK, "protected") })
+	assert.NoError(t, rbnsGin.ValidatePolicy(router, p))
+
+	for _, url := range []string{"/admin/", "/admin/users"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, url, "user1"))
+		assert.Equal(t, http.StatusForbidden, w.Result().StatusCode, url)
+		assert.NotContains(t, w.Body.String(), "protected", url)
+	}
+	// The router redirects /admin to /admin/ without running the handler.
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/admin", "user1"))
+	assert.Equal(t, http.StatusMovedPermanently, w.Result().StatusCode)
+}
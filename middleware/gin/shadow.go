@@ -0,0 +1,55 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+const shadowKey = "rbns-shadow"
+
+// ShadowMode makes the permission checks of the following handlers run in
+// shadow mode on the routes s enables: failures are recorded and the
+// request goes through. Routes are matched by their registered pattern.
+func ShadowMode(s *middleware.Shadow) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(shadowKey, s)
+		c.Next()
+	}
+}
+
+func route(c *gin.Context) string {
+	if r := c.FullPath(); r != "" {
+		return r
+	}
+	return c.Request.URL.Path
+}
+
+// reject classifies err and either records it when the route is in shadow
+// mode, returning false, or writes the response through eh.
+func reject(c *gin.Context, eh ErrorHandler, err error, permissionNames []string) bool {
+	f := middleware.Classify(err)
+	if v, ok := c.Get(shadowKey); ok {
+		if s, ok := v.(*middleware.Shadow); ok && s.Enabled(c.Request.Method, route(c)) {
+			d := middleware.ShadowDenial{
+				Method:      c.Request.Method,
+				Route:       route(c),
+				Permissions: permissionNames,
+				Failure:     f,
+			}
+			if a, ok := Authorizer(c); ok {
+				d.UserKey, d.OrganizationName = a.UserKey(), a.OrganizationName()
+			}
+			s.Record(d)
+			return false
+		}
+	}
+	eh(c, f)
+	return true
+}
+
+func policyPermissions(p *middleware.Policy, c *gin.Context) []string {
+	if rule, ok := p.Match(c.Request.Method, c.Request.URL.Path); ok {
+		return rule.Permissions
+	}
+	return nil
+}
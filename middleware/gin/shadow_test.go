@@ -0,0 +1,58 @@
+package gin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowMode(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	var denials []middleware.ShadowDenial
+	shadow := middleware.NewShadow(middleware.ShadowRecorderFunc(func(d middleware.ShadowDenial) {
+		denials = append(denials, d)
+	}))
+	shadow.SetRoute(http.MethodDelete, "/users/:id", true)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.Use(rbnsGin.Client(client), rbnsGin.ShadowMode(shadow))
+	router.GET("/users/:id", rbnsGin.PermissionCheck(getUser, "read:test"), ok)
+	router.DELETE("/users/:id", rbnsGin.PermissionCheck(getUser, "delete:test"), ok)
+
+	serve := func(method, url, user string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(method, url, user))
+		return w.Result().StatusCode
+	}
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/users/2", "user1"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/users/2", "user2"))
+	require.Len(t, denials, 1)
+	assert.Equal(t, http.MethodDelete, denials[0].Method)
+	assert.Equal(t, "/users/:id", denials[0].Route)
+	assert.Equal(t, "user1", denials[0].UserKey)
+	assert.Equal(t, "default", denials[0].OrganizationName)
+	assert.Equal(t, []string{"delete:test"}, denials[0].Permissions)
+	assert.Equal(t, middleware.FailureForbidden, denials[0].Failure.Kind)
+
+	shadow.SetEnabled(true)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "/users/2", "user2"))
+	shadow.SetRoute(http.MethodDelete, "/users/:id", false)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/users/2", "user1"))
+	assert.Len(t, denials, 2)
+}
@@ -0,0 +1,14 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// Extractor reads the user key and organization of a request through s, with
+// path parameters taken from the route.
+func Extractor(s middleware.Subject) GetUserOrganization {
+	return func(c *gin.Context) (string, string, error) {
+		return s.Extract(middleware.NewSubjectRequest(c.Request, c.Param))
+	}
+}
@@ -0,0 +1,48 @@
+package gin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractor(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	getUser := rbnsGin.Extractor(middleware.Subject{
+		UserKey:      middleware.FirstOf(middleware.Header("X-User"), middleware.PathParam("user")),
+		Organization: middleware.PathParam("organization"),
+	})
+	router := gin.New()
+	router.Use(rbnsGin.Client(client))
+	router.GET("/:organization/users/:user", rbnsGin.PermissionCheck(getUser, "read:test"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		url, user string
+		status    int
+	}{
+		{"/default/users/user1", "", http.StatusNoContent},
+		{"/default/users/user2", "user1", http.StatusNoContent},
+		{"/default/users/user2", "", http.StatusForbidden},
+		{"/other/users/user1", "", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request(http.MethodGet, c.url, c.user))
+		assert.Equal(t, c.status, w.Result().StatusCode, c.url)
+	}
+}
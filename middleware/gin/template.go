@@ -0,0 +1,64 @@
+package gin
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// HTMLRender is a gin HTML renderer for templates parsed with
+// middleware.TemplateFuncs. Each render executes a clone of Template, with
+// the permission functions bound to the request when rendered through HTML.
+//
+//	t := template.Must(template.New("").Funcs(middleware.TemplateFuncs()).ParseGlob("views/*"))
+//	router.HTMLRender = rbnsGin.HTMLRender{Template: t}
+type HTMLRender struct {
+	Template *template.Template
+}
+
+type boundData struct {
+	authorizer *middleware.Authorizer
+	data       interface{}
+}
+
+func (r HTMLRender) Instance(name string, data interface{}) render.Render {
+	var (
+		t   *template.Template
+		err error
+	)
+	if b, ok := data.(boundData); ok {
+		t, err = middleware.BindTemplate(r.Template, name, b.authorizer)
+		data = b.data
+	} else {
+		t, err = r.Template.Clone()
+	}
+	if err != nil {
+		return errorRender{err}
+	}
+	return render.HTML{Template: t, Name: name, Data: data}
+}
+
+type errorRender struct {
+	err error
+}
+
+func (r errorRender) Render(http.ResponseWriter) error {
+	return r.err
+}
+
+func (r errorRender) WriteContentType(w http.ResponseWriter) {}
+
+// HTML renders the named template like c.HTML, with "can", "canAll" and
+// "canAny" answering for the request's subject. Permissions named by string
+// literals in the template and the templates it calls are checked
+// concurrently before rendering and every answer is memoized for the
+// request. Without a resolved subject the functions fail as for c.HTML.
+func HTML(c *gin.Context, code int, name string, obj interface{}) {
+	if a, ok := Authorizer(c); ok {
+		obj = boundData{authorizer: a, data: obj}
+	}
+	c.HTML(code, name, obj)
+}
@@ -0,0 +1,45 @@
+package gin_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("delete:doc", "")
+	srv.AddRole("reader", "read:doc")
+	srv.AddRole("admin", "read:doc", "delete:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	srv.AddUser("default", "user2", "admin")
+	client := srv.Client(t)
+
+	router := gin.New()
+	router.HTMLRender = rbnsGin.HTMLRender{Template: template.Must(template.New("doc.html").Funcs(middleware.TemplateFuncs()).Parse(
+		`<p>{{.}}</p>{{if can "delete:doc"}}<button>Delete</button>{{end}}`,
+	))}
+	router.Use(rbnsGin.Client(client))
+	router.GET("/docs/:id", rbnsGin.PermissionCheck(getUser, "read:doc"), func(c *gin.Context) {
+		rbnsGin.HTML(c, http.StatusOK, "doc.html", c.Param("id"))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/docs/1", "user1"))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "<p>1</p>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request(http.MethodGet, "/docs/2", "user2"))
+	assert.Equal(t, "<p>2</p><button>Delete</button>", w.Body.String())
+}
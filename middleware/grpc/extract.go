@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	ErrNoSubject = errors.New("subject is not present in the request")
+)
+
+// GetUserOrganization extracts the subject of an incoming call.
+type GetUserOrganization func(ctx context.Context) (userKey string, organizationName string, err error)
+
+// MetadataExtractor reads the user key and organization name from incoming
+// metadata keys, for example "x-user" and "x-organization".
+func MetadataExtractor(userKeyHeader, organizationHeader string) GetUserOrganization {
+	return func(ctx context.Context) (string, string, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return "", "", ErrNoSubject
+		}
+		users := md.Get(userKeyHeader)
+		organizations := md.Get(organizationHeader)
+		if len(users) == 0 || users[0] == "" || len(organizations) == 0 || organizations[0] == "" {
+			return "", "", ErrNoSubject
+		}
+		return users[0], organizations[0], nil
+	}
+}
+
+// PeerCertificateExtractor reads the user key from the common name and the
+// organization name from the first organization of the verified client
+// certificate.
+func PeerCertificateExtractor() GetUserOrganization {
+	return func(ctx context.Context) (string, string, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return "", "", ErrNoSubject
+		}
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+			return "", "", ErrNoSubject
+		}
+		subject := info.State.VerifiedChains[0][0].Subject
+		if subject.CommonName == "" || len(subject.Organization) == 0 {
+			return "", "", ErrNoSubject
+		}
+		return subject.CommonName, subject.Organization[0], nil
+	}
+}
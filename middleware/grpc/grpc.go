@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the ErrorInfo detail attached to rejections.
+const ErrorDomain = "rbns"
+
+type options struct {
+	defaultDeny bool
+}
+
+type Option func(o *options)
+
+// WithDefaultDeny rejects methods the resolver does not know instead of
+// letting them through.
+func WithDefaultDeny() Option {
+	return func(o *options) {
+		o.defaultDeny = true
+	}
+}
+
+type authorizer struct {
+	client   *rbns.Client
+	fn       GetUserOrganization
+	resolver PermissionResolver
+	options
+}
+
+func newAuthorizer(client *rbns.Client, fn GetUserOrganization, resolver PermissionResolver, opts []Option) *authorizer {
+	a := &authorizer{client: client, fn: fn, resolver: resolver}
+	for _, opt := range opts {
+		opt(&a.options)
+	}
+	return a
+}
+
+func (a *authorizer) authorize(ctx context.Context, fullMethod string) error {
+	permissionNames, ok := a.resolver(fullMethod)
+	if !ok {
+		if a.defaultDeny {
+			return rejection(codes.PermissionDenied, "UNMAPPED_METHOD", "method has no permission mapping", map[string]string{"method": fullMethod})
+		}
+		return nil
+	}
+	if len(permissionNames) == 0 {
+		return nil
+	}
+	userKey, organizationName, err := a.fn(ctx)
+	if err != nil {
+		return rejection(codes.Unauthenticated, "UNAUTHENTICATED", err.Error(), map[string]string{"method": fullMethod})
+	}
+	// The check is bound to the RPC so that its deadline and cancellation
+	// reach the rbns server.
+	granted, err := a.client.CheckContext(ctx, userKey, organizationName, permissionNames...)
+	if err == nil && !granted {
+		err = middleware.ErrForbidden
+	}
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, middleware.ErrForbidden):
+		return rejection(codes.PermissionDenied, "PERMISSION_DENIED", "permission denied", map[string]string{
+			"method":       fullMethod,
+			"user":         userKey,
+			"organization": organizationName,
+			"permissions":  strings.Join(permissionNames, ","),
+		})
+	default:
+		code := codes.Internal
+		if middleware.Classify(err).Kind == middleware.FailureUnavailable {
+			code = codes.Unavailable
+		}
+		return status.Errorf(code, "permission check failed: %s", err)
+	}
+}
+
+func rejection(code codes.Code, reason, message string, metadata map[string]string) error {
+	s := status.New(code, message)
+	if d, err := s.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: ErrorDomain, Metadata: metadata}); err == nil {
+		s = d
+	}
+	return s.Err()
+}
+
+// UnaryServerInterceptor checks the permissions resolved for each method
+// before calling the handler. A failing extractor yields Unauthenticated and
+// a denied check PermissionDenied, both with an ErrorInfo detail.
+func UnaryServerInterceptor(client *rbns.Client, fn GetUserOrganization, resolver PermissionResolver, opts ...Option) grpc.UnaryServerInterceptor {
+	a := newAuthorizer(client, fn, resolver, opts)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.authorize(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor; the check runs once when the stream opens.
+func StreamServerInterceptor(client *rbns.Client, fn GetUserOrganization, resolver PermissionResolver, opts ...Option) grpc.StreamServerInterceptor {
+	a := newAuthorizer(client, fn, resolver, opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
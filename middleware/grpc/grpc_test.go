@@ -0,0 +1,211 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	rbnsGRPC "github.com/n-creativesystem/go-rbns/middleware/grpc"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	checkMethod = "/grpc.health.v1.Health/Check"
+	watchMethod = "/grpc.health.v1.Health/Watch"
+)
+
+func newClient(t *testing.T) (*tests.Server, *rbns.Client) {
+	srv := tests.NewServer()
+	srv.AddPermission("read:health", "")
+	srv.AddPermission("watch:health", "")
+	srv.AddRole("reader", "read:health")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	return srv, srv.Client(t)
+}
+
+// serve starts a health service behind the interceptors and returns a client
+// for it.
+func serve(t *testing.T, client *rbns.Client, resolver rbnsGRPC.PermissionResolver, opts ...rbnsGRPC.Option) healthpb.HealthClient {
+	fn := rbnsGRPC.MetadataExtractor("x-user", "x-organization")
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(rbnsGRPC.UnaryServerInterceptor(client, fn, resolver, opts...)),
+		grpc.StreamInterceptor(rbnsGRPC.StreamServerInterceptor(client, fn, resolver, opts...)),
+	)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	lis := bufconn.Listen(1024 * 1024)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	con, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = con.Close() })
+	return healthpb.NewHealthClient(con)
+}
+
+func as(userKey string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-user", userKey, "x-organization", "default")
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	srv, client := newClient(t)
+	defer srv.Close()
+	health := serve(t, client, rbnsGRPC.Table{checkMethod: {"read:health"}}.Resolve)
+
+	_, err := health.Check(as("user1"), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	_, err = health.Check(as("user2"), &healthpb.HealthCheckRequest{})
+	s := status.Convert(err)
+	assert.Equal(t, codes.PermissionDenied, s.Code())
+	require.Len(t, s.Details(), 1)
+	info := s.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, "PERMISSION_DENIED", info.Reason)
+	assert.Equal(t, rbnsGRPC.ErrorDomain, info.Domain)
+	assert.Equal(t, map[string]string{
+		"method":       checkMethod,
+		"user":         "user2",
+		"organization": "default",
+		"permissions":  "read:health",
+	}, info.Metadata)
+
+	_, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	srv, client := newClient(t)
+	defer srv.Close()
+	health := serve(t, client, rbnsGRPC.Table{watchMethod: {"watch:health"}}.Resolve)
+
+	stream, err := health.Watch(as("user1"), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestDefaultDeny(t *testing.T) {
+	srv, client := newClient(t)
+	defer srv.Close()
+
+	health := serve(t, client, rbnsGRPC.Table{}.Resolve)
+	_, err := health.Check(as("user2"), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	health = serve(t, client, rbnsGRPC.Table{watchMethod: nil}.Resolve, rbnsGRPC.WithDefaultDeny())
+	_, err = health.Check(as("user1"), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	stream, err := health.Watch(as("user2"), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+}
+
+func TestOptionResolver(t *testing.T) {
+	files := new(protoregistry.Files)
+	options, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("options.proto"),
+		Package:    proto.String("example"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("permissions"),
+			Number:   proto.Int32(50001),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+		}},
+		Syntax: proto.String("proto3"),
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	require.NoError(t, files.RegisterFile(options))
+	ext := dynamicpb.NewExtensionType(options.Extensions().Get(0))
+
+	methodOptions := &descriptorpb.MethodOptions{}
+	list := methodOptions.ProtoReflect().NewField(ext.TypeDescriptor()).List()
+	list.Append(protoreflect.ValueOfString("create:test"))
+	list.Append(protoreflect.ValueOfString("read:test"))
+	methodOptions.ProtoReflect().Set(ext.TypeDescriptor(), protoreflect.ValueOfList(list))
+
+	service, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("service.proto"),
+		Package:    proto.String("example"),
+		Dependency: []string{"google/protobuf/empty.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Things"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Create"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty"), Options: methodOptions},
+				{Name: proto.String("List"), InputType: proto.String(".google.protobuf.Empty"), OutputType: proto.String(".google.protobuf.Empty")},
+			},
+		}},
+		Syntax: proto.String("proto3"),
+	}, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	require.NoError(t, files.RegisterFile(service))
+
+	resolve := rbnsGRPC.OptionResolverFrom(files, ext)
+	permissionNames, ok := resolve("/example.Things/Create")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"create:test", "read:test"}, permissionNames)
+	_, ok = resolve("/example.Things/List")
+	assert.False(t, ok)
+	_, ok = resolve("/example.Things/Missing")
+	assert.False(t, ok)
+}
+
+func TestNotReady(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	client := srv.Client(t, rbns.WithNonBlocking(0))
+	health := serve(t, client, rbnsGRPC.Table{checkMethod: {"read:health"}}.Resolve)
+
+	_, err := health.Check(as("user1"), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestCanceledCheck(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	stopped := make(chan error, 1)
+	block := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method != "/ncs.protobuf.Permission/Check" {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return ctx.Err()
+	}
+	client := srv.Client(t, rbns.WithDialOption(grpc.WithUnaryInterceptor(block)))
+	health := serve(t, client, rbnsGRPC.Table{checkMethod: {"read:health"}}.Resolve)
+
+	ctx, cancel := context.WithTimeout(as("user1"), 50*time.Millisecond)
+	defer cancel()
+	_, err := health.Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	select {
+	case err := <-stopped:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("check not stopped with the RPC")
+	}
+}
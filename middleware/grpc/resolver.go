@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// PermissionResolver returns the permissions required by a full method name
+// such as "/pkg.Service/Method". ok is false for methods it does not know;
+// an empty list for a known method makes it public.
+type PermissionResolver func(fullMethod string) (permissionNames []string, ok bool)
+
+// Table maps full method names to their required permissions.
+type Table map[string][]string
+
+func (t Table) Resolve(fullMethod string) ([]string, bool) {
+	permissionNames, ok := t[fullMethod]
+	return permissionNames, ok
+}
+
+// OptionResolver reads the required permissions from a method option, a
+// string or repeated string extension of google.protobuf.MethodOptions
+// declared by the service's own proto files, for example
+//
+//	extend google.protobuf.MethodOptions {
+//	    repeated string permissions = 50001;
+//	}
+//	rpc Create (Request) returns (Response) {
+//	    option (permissions) = "create:test";
+//	}
+func OptionResolver(ext protoreflect.ExtensionType) PermissionResolver {
+	return OptionResolverFrom(protoregistry.GlobalFiles, ext)
+}
+
+// OptionResolverFrom is OptionResolver looking methods up in files.
+func OptionResolverFrom(files *protoregistry.Files, ext protoreflect.ExtensionType) PermissionResolver {
+	type entry struct {
+		permissionNames []string
+		ok              bool
+	}
+	var cache sync.Map
+	field := ext.TypeDescriptor()
+	return func(fullMethod string) ([]string, bool) {
+		if v, ok := cache.Load(fullMethod); ok {
+			e := v.(entry)
+			return e.permissionNames, e.ok
+		}
+		e := entry{}
+		name := strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", 1)
+		if d, err := files.FindDescriptorByName(protoreflect.FullName(name)); err == nil {
+			if md, ok := d.(protoreflect.MethodDescriptor); ok && md.Options() != nil {
+				opts := md.Options().ProtoReflect()
+				if opts.Has(field) {
+					e.ok = true
+					v := opts.Get(field)
+					if field.IsList() {
+						list := v.List()
+						for i := 0; i < list.Len(); i++ {
+							e.permissionNames = append(e.permissionNames, list.Get(i).String())
+						}
+					} else {
+						e.permissionNames = []string{v.String()}
+					}
+				}
+			}
+		}
+		cache.Store(fullMethod, e)
+		return e.permissionNames, e.ok
+	}
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// JWK is a JSON Web Key of type "oct", "RSA" or "EC".
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	key interface{}
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadJWKS reads a JWKS file.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var s JWKS
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	for i := range s.Keys {
+		if err := s.Keys[i].decode(); err != nil {
+			return nil, fmt.Errorf("jwks: key %d (%q): %w", i, s.Keys[i].Kid, err)
+		}
+	}
+	return &s, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func (k *JWK) decode() error {
+	switch k.Kty {
+	case "oct":
+		b, err := decodeSegment(k.K)
+		if err != nil || len(b) == 0 {
+			return errors.New("invalid k")
+		}
+		k.key = b
+	case "RSA":
+		n, err := decodeSegment(k.N)
+		if err != nil || len(n) == 0 {
+			return errors.New("invalid n")
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return errors.New("invalid e")
+		}
+		k.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return errors.New("invalid x")
+		}
+		y, err := decodeSegment(k.Y)
+		if err != nil {
+			return errors.New("invalid y")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return errors.New("point is not on the curve")
+		}
+		k.key = pub
+	default:
+		return fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	return nil
+}
+
+type algorithm struct {
+	kty  string
+	hash crypto.Hash
+}
+
+var algorithms = map[string]algorithm{
+	"HS256": {"oct", crypto.SHA256},
+	"HS384": {"oct", crypto.SHA384},
+	"HS512": {"oct", crypto.SHA512},
+	"RS256": {"RSA", crypto.SHA256},
+	"RS384": {"RSA", crypto.SHA384},
+	"RS512": {"RSA", crypto.SHA512},
+	"ES256": {"EC", crypto.SHA256},
+	"ES384": {"EC", crypto.SHA384},
+	"ES512": {"EC", crypto.SHA512},
+}
+
+func (k *JWK) verify(alg algorithm, signed, sig []byte) bool {
+	h := alg.hash.New()
+	switch key := k.key.(type) {
+	case []byte:
+		mac := hmac.New(alg.hash.New, key)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	case *rsa.PublicKey:
+		h.Write(signed)
+		return rsa.VerifyPKCS1v15(key, alg.hash, h.Sum(nil), sig) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		h.Write(signed)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(key, h.Sum(nil), r, s)
+	}
+	return false
+}
+
+// Claims are the decoded claims of a verified token.
+type Claims map[string]interface{}
+
+// Get looks up a dotted path such as "sub" or "org.name".
+func (c Claims) Get(path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(c)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// JWTVerifier verifies compact JWS tokens against a key set.
+type JWTVerifier struct {
+	keys     *JWKS
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+type JWTOption func(v *JWTVerifier)
+
+// WithIssuer requires the "iss" claim to equal issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience requires the "aud" claim to contain audience.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway tolerates clock skew when checking "exp" and "nbf".
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(v *JWTVerifier) {
+		v.leeway = leeway
+	}
+}
+
+func NewJWTVerifier(keys *JWKS, opts ...JWTOption) *JWTVerifier {
+	v := &JWTVerifier{keys: keys, now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+func invalidToken(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// Verify checks the signature of token with the key named by its "kid", or
+// with every key of the matching type when it has none, and then checks
+// "exp", "nbf", "iss" and "aud".
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("malformed")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	b, err := decodeSegment(parts[0])
+	if err != nil || json.Unmarshal(b, &header) != nil {
+		return nil, invalidToken("malformed header")
+	}
+	alg, ok := algorithms[header.Alg]
+	if !ok {
+		return nil, invalidToken("unsupported algorithm %q", header.Alg)
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, invalidToken("malformed signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for i := range v.keys.Keys {
+		k := &v.keys.Keys[i]
+		if k.Kty != alg.kty || (k.Alg != "" && k.Alg != header.Alg) || (header.Kid != "" && k.Kid != header.Kid) {
+			continue
+		}
+		if k.verify(alg, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, invalidToken("signature verification failed")
+	}
+	b, err = decodeSegment(parts[1])
+	if err != nil {
+		return nil, invalidToken("malformed claims")
+	}
+	var claims Claims
+	if err := json.Unmarshal(b, &claims); err != nil {
+		return nil, invalidToken("malformed claims")
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *JWTVerifier) validate(claims Claims) error {
+	now := v.now()
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(v.leeway)) {
+		return invalidToken("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.leeway).Before(time.Unix(int64(nbf), 0)) {
+		return invalidToken("token is not valid yet")
+	}
+	if v.issuer != "" && claims["iss"] != v.issuer {
+		return invalidToken("unexpected issuer")
+	}
+	if v.audience != "" {
+		switch aud := claims["aud"].(type) {
+		case string:
+			if aud == v.audience {
+				return nil
+			}
+		case []interface{}:
+			for _, a := range aud {
+				if a == v.audience {
+					return nil
+				}
+			}
+		}
+		return invalidToken("unexpected audience")
+	}
+	return nil
+}
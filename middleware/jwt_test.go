@@ -0,0 +1,104 @@
+package middleware_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var b64 = base64.RawURLEncoding
+
+type testKeys struct {
+	secret []byte
+	rsa    *rsa.PrivateKey
+	ec     *ecdsa.PrivateKey
+	jwks   *middleware.JWKS
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	k := &testKeys{secret: []byte("0123456789abcdef0123456789abcdef")}
+	var err error
+	k.rsa, err = rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	k.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kid": "hs", "kty": "oct", "k": b64.EncodeToString(k.secret)},
+		{"kid": "rs", "kty": "RSA", "alg": "RS256", "n": b64.EncodeToString(k.rsa.N.Bytes()), "e": b64.EncodeToString(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kid": "es", "kty": "EC", "crv": "P-256", "x": b64.EncodeToString(k.ec.X.Bytes()), "y": b64.EncodeToString(k.ec.Y.Bytes())},
+	}})
+	require.NoError(t, err)
+	k.jwks, err = middleware.ParseJWKS(data)
+	require.NoError(t, err)
+	return k
+}
+
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64.EncodeToString(header) + "." + b64.EncodeToString(payload)
+	h := crypto.SHA256.New()
+	h.Write([]byte(signed))
+	var sig []byte
+	switch alg[:2] {
+	case "HS":
+		mac := hmac.New(crypto.SHA256.New, k.secret)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case "RS":
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, h.Sum(nil))
+		require.NoError(t, err)
+	case "ES":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, h.Sum(nil))
+		require.NoError(t, err)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+	return signed + "." + b64.EncodeToString(sig)
+}
+
+func TestJWTVerifier(t *testing.T) {
+	keys := newTestKeys(t)
+	v := middleware.NewJWTVerifier(keys.jwks, middleware.WithIssuer("issuer"), middleware.WithAudience("rbns"))
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	claims := map[string]interface{}{"sub": "user1", "iss": "issuer", "aud": []string{"other", "rbns"}, "exp": exp}
+
+	for _, alg := range []string{"HS256", "RS256", "ES256"} {
+		c, err := v.Verify(keys.sign(t, alg, "", claims))
+		require.NoError(t, err, alg)
+		assert.Equal(t, "user1", c["sub"], alg)
+	}
+
+	invalid := map[string]string{
+		"wrong kid":   keys.sign(t, "RS256", "es", claims),
+		"alg none":    b64.EncodeToString([]byte(`{"alg":"none"}`)) + "." + b64.EncodeToString([]byte(`{"sub":"user1"}`)) + ".",
+		"tampered":    keys.sign(t, "HS256", "hs", claims) + "x",
+		"malformed":   "abc",
+		"expired":     keys.sign(t, "HS256", "hs", map[string]interface{}{"sub": "user1", "iss": "issuer", "aud": "rbns", "exp": float64(time.Now().Add(-time.Hour).Unix())}),
+		"not yet":     keys.sign(t, "HS256", "hs", map[string]interface{}{"sub": "user1", "iss": "issuer", "aud": "rbns", "nbf": exp}),
+		"wrong iss":   keys.sign(t, "HS256", "hs", map[string]interface{}{"sub": "user1", "iss": "other", "aud": "rbns"}),
+		"missing aud": keys.sign(t, "HS256", "hs", map[string]interface{}{"sub": "user1", "iss": "issuer"}),
+	}
+	for name, token := range invalid {
+		c, err := v.Verify(token)
+		assert.ErrorIs(t, err, middleware.ErrInvalidToken, name)
+		assert.Nil(t, c, name)
+	}
+
+	_, err := middleware.ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	assert.Error(t, err)
+}
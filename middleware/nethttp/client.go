@@ -0,0 +1,57 @@
+package nethttp
+
+import (
+	"context"
+	"net/http"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+type contextKey struct{}
+
+var clientKey = contextKey{}
+
+// NewContext returns a copy of ctx carrying client.
+func NewContext(ctx context.Context, client *rbns.Client) context.Context {
+	return context.WithValue(ctx, clientKey, client)
+}
+
+// FromContext returns the client injected by Client or ClientWithOptions.
+func FromContext(ctx context.Context) (*rbns.Client, bool) {
+	client, ok := ctx.Value(clientKey).(*rbns.Client)
+	return client, ok
+}
+
+// clientWithOptions connects a client for the request. The returned func
+// closes it and must be called once the request is served.
+func clientWithOptions(w http.ResponseWriter, r *http.Request, eh ErrorHandler, opts ...rbns.Option) (*http.Request, func(), bool) {
+	client, err := rbns.Connection(r.Context(), opts...)
+	if err != nil {
+		eh(w, r, http.StatusInternalServerError, err)
+		return r, nil, false
+	}
+	return r.WithContext(NewContext(r.Context(), client)), func() { _ = client.Close() }, true
+}
+
+// ClientWithOptions connects a client for each request and closes it once
+// the request is served. Prefer Client with a shared client.
+func ClientWithOptions(opts ...rbns.Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, closeClient, ok := clientWithOptions(w, r, DefaultErrorHandler, opts...)
+			if !ok {
+				return
+			}
+			defer closeClient()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func Client(client *rbns.Client) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), client)))
+		})
+	}
+}
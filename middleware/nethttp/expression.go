@@ -0,0 +1,42 @@
+package nethttp
+
+import (
+	"net/http"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// ExpressionCheck requires a boolean permission expression such as
+// "create:doc && (approve:doc || role-admin:org)". The expression is
+// compiled here and a syntax error panics, so it surfaces at startup.
+func ExpressionCheck(fn GetUserOrganization, expression string) func(http.Handler) http.Handler {
+	return ExpressionCheckWithErrorHandler(fn, DefaultErrorHandler, middleware.MustParseExpression(expression))
+}
+
+// ExpressionCheckWithErrorHandler is ExpressionCheck for a compiled
+// expression with eh writing the response of rejected requests. The status
+// passed to eh follows middleware.Classify.
+func ExpressionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, e *middleware.Expression) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := httpExpressionCheck(r, fn, e); err != nil {
+				f := middleware.Classify(err)
+				eh(w, r, f.Status(), f.Err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func httpExpressionCheck(r *http.Request, fn GetUserOrganization, e *middleware.Expression) error {
+	client, ok := FromContext(r.Context())
+	if !ok || client == nil {
+		return middleware.NewFailure(middleware.FailureNoClient, ErrNoSDK)
+	}
+	userKey, organizationName, err := fn(r)
+	if err != nil {
+		return middleware.NewFailure(middleware.FailureUnauthenticated, err)
+	}
+	return e.Check(middleware.NewAuthorizer(client, userKey, organizationName))
+}
@@ -0,0 +1,27 @@
+package nethttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rbnsHTTP "github.com/n-creativesystem/go-rbns/middleware/nethttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionCheck(t *testing.T) {
+	srv, client := newServer(t)
+	defer srv.Close()
+
+	handler := rbnsHTTP.Client(client)(
+		rbnsHTTP.ExpressionCheck(getUser, "read:test && (create:test || delete:test)")(http.HandlerFunc(echoUser)),
+	)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, request(http.MethodGet, "/api/users/user1", "user1"))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, request(http.MethodGet, "/api/users/user1", "user2"))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	assert.Panics(t, func() { rbnsHTTP.ExpressionCheck(getUser, "read:test &&") })
+}
@@ -0,0 +1,80 @@
+package nethttp
+
+import (
+	"errors"
+	"net/http"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+var (
+	ErrNoSDK = errors.New("client sdk is empty")
+)
+
+type GetUserOrganization func(r *http.Request) (userKey string, organizationName string, err error)
+
+// ErrorHandler writes the response for a request that failed with status.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, err error)
+
+// DefaultErrorHandler writes the status text as a plain text body.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, status int, err error) {
+	http.Error(w, http.StatusText(status), status)
+}
+
+func httpPermissionCheck(r *http.Request, fn GetUserOrganization, permissionNames ...string) error {
+	client, ok := FromContext(r.Context())
+	if !ok || client == nil {
+		return middleware.NewFailure(middleware.FailureNoClient, ErrNoSDK)
+	}
+	userKey, organizationName, err := fn(r)
+	if err != nil {
+		return middleware.NewFailure(middleware.FailureUnauthenticated, err)
+	}
+	return middleware.PermissionCheck(client, userKey, organizationName, permissionNames...)
+}
+
+// permissionCheck reports failures to eh with the status of
+// middleware.Classify, as ExpressionCheck does.
+func permissionCheck(fn GetUserOrganization, eh ErrorHandler, permissionNames []string, opts []rbns.Option) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts != nil {
+				var (
+					closeClient func()
+					ok          bool
+				)
+				if r, closeClient, ok = clientWithOptions(w, r, eh, opts...); !ok {
+					return
+				}
+				defer closeClient()
+			}
+			if err := httpPermissionCheck(r, fn, permissionNames...); err != nil {
+				f := middleware.Classify(err)
+				eh(w, r, f.Status(), f.Err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Option) func(http.Handler) http.Handler {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, append([]rbns.Option{}, opts...))
+}
+
+// PermissionCheckWithErrorHandler is PermissionCheck with eh writing the
+// response of rejected requests.
+func PermissionCheckWithErrorHandler(fn GetUserOrganization, eh ErrorHandler, permissionNames ...string) func(http.Handler) http.Handler {
+	return permissionCheck(fn, eh, permissionNames, nil)
+}
+
+func PermissionCheck(fn GetUserOrganization, permissionNames ...string) func(http.Handler) http.Handler {
+	return permissionCheck(fn, DefaultErrorHandler, permissionNames, nil)
+}
+
+// TypedPermissionCheck is PermissionCheck taking the constants generated by
+// rbns-gen.
+func TypedPermissionCheck(fn GetUserOrganization, permissions ...rbns.Permission) func(http.Handler) http.Handler {
+	return PermissionCheck(fn, rbns.PermissionNames(permissions...)...)
+}
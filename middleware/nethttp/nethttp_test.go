@@ -0,0 +1,147 @@
+package nethttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	rbnsHTTP "github.com/n-creativesystem/go-rbns/middleware/nethttp"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func getUser(r *http.Request) (userKey, organizationName string, err error) {
+	userKey = r.Header.Get("X-User")
+	organizationName = "default"
+	err = nil
+	return
+}
+
+func getUserNoExistsOrganization(r *http.Request) (userKey, organizationName string, err error) {
+	userKey = r.Header.Get("X-User")
+	organizationName = "default2"
+	err = nil
+	return
+}
+
+func request(method, url, userKey string) *http.Request {
+	req := httptest.NewRequest(method, url, nil)
+	req.Header.Set("X-User", userKey)
+	return req
+}
+
+func echoUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"user": strings.TrimPrefix(r.URL.Path, "/api/users/")})
+}
+
+// methods routes by request method, as a router such as chi would.
+func methods(handlers map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := handlers[r.Method]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+}
+
+func newServer(t *testing.T) (*tests.Server, *rbns.Client) {
+	srv := tests.NewServer()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("writer", "create:test", "read:test")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "writer")
+	srv.AddUser("default", "user2", "reader")
+	return srv, srv.Client(t)
+}
+
+func TestHTTPWAF(t *testing.T) {
+	srv, client := newServer(t)
+	defer srv.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/users/", methods(map[string]http.Handler{
+		http.MethodPost:   rbnsHTTP.PermissionCheck(getUser, "create:test")(http.HandlerFunc(echoUser)),
+		http.MethodGet:    rbnsHTTP.PermissionCheck(getUser, "read:test")(http.HandlerFunc(echoUser)),
+		http.MethodDelete: rbnsHTTP.PermissionCheck(getUser, "delete:test")(http.HandlerFunc(echoUser)),
+	}))
+	mux.Handle("/api/no-users/", rbnsHTTP.PermissionCheck(getUserNoExistsOrganization, "read:test")(http.HandlerFunc(echoUser)))
+	router := rbnsHTTP.Client(client)(mux)
+
+	type expect struct {
+		method, url, user string
+		status            int
+		body              string
+	}
+	run := func(e expect) func(t *testing.T) {
+		return func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request(e.method, e.url, e.user))
+			assert.Equal(t, e.status, w.Result().StatusCode)
+			assert.Equal(t, e.body, strings.TrimSpace(w.Body.String()))
+		}
+	}
+	forbidden := http.StatusText(http.StatusForbidden)
+	cases := tests.Cases{
+		{Name: "user1 create ok", Fn: run(expect{http.MethodPost, "/api/users/user1", "user1", http.StatusOK, `{"user":"user1"}`})},
+		{Name: "user1 read ok", Fn: run(expect{http.MethodGet, "/api/users/user2", "user1", http.StatusOK, `{"user":"user2"}`})},
+		{Name: "user1 delete ng", Fn: run(expect{http.MethodDelete, "/api/users/user2", "user1", http.StatusForbidden, forbidden})},
+		{Name: "user2 create ng", Fn: run(expect{http.MethodPost, "/api/users/user2", "user2", http.StatusForbidden, forbidden})},
+		{Name: "user2 read ok", Fn: run(expect{http.MethodGet, "/api/users/user1", "user2", http.StatusOK, `{"user":"user1"}`})},
+		{Name: "user3 no exists read ng", Fn: run(expect{http.MethodGet, "/api/users/user1", "user3", http.StatusForbidden, forbidden})},
+		{Name: "organization no exists read ng", Fn: run(expect{http.MethodGet, "/api/no-users/user1", "user1", http.StatusForbidden, forbidden})},
+	}
+	cases.Run(t)
+}
+
+func TestErrorHandler(t *testing.T) {
+	srv, client := newServer(t)
+	defer srv.Close()
+
+	eh := func(w http.ResponseWriter, r *http.Request, status int, err error) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	}
+	failing := func(r *http.Request) (string, string, error) {
+		return "", "", errors.New("no subject")
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	rbnsHTTP.Client(client)(rbnsHTTP.PermissionCheckWithErrorHandler(failing, eh, "read:test")(ok)).ServeHTTP(w, request(http.MethodGet, "/", "user1"))
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	assert.Equal(t, `{"error":"no subject"}`, strings.TrimSpace(w.Body.String()))
+
+	w = httptest.NewRecorder()
+	rbnsHTTP.PermissionCheckWithErrorHandler(getUser, eh, "read:test")(ok).ServeHTTP(w, request(http.MethodGet, "/", "user1"))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	assert.Equal(t, `{"error":"client sdk is empty"}`, strings.TrimSpace(w.Body.String()))
+
+	w = httptest.NewRecorder()
+	rbnsHTTP.PermissionCheckWithClientOptions(getUser, []string{"read:test"}, rbns.WithHost(srv.Addr()), rbns.WithDialOption(srv.DialOptions()...))(ok).ServeHTTP(w, request(http.MethodGet, "/", "user2"))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestUnreachableServer(t *testing.T) {
+	srv, client := newServer(t)
+	srv.Close()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for name, h := range map[string]http.Handler{
+		"permission": rbnsHTTP.PermissionCheck(getUser, "read:test")(ok),
+		"expression": rbnsHTTP.ExpressionCheck(getUser, "read:test")(ok),
+	} {
+		w := httptest.NewRecorder()
+		rbnsHTTP.Client(client)(h).ServeHTTP(w, request(http.MethodGet, "/", "user1"))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Result().StatusCode, name)
+	}
+}
@@ -0,0 +1,26 @@
+package nethttp
+
+import (
+	"net/http"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+)
+
+// Extractor reads the user key and organization of a request through s.
+// net/http has no path parameters, so middleware.PathParam is always absent;
+// use ExtractorWithParams with the router's accessor instead.
+func Extractor(s middleware.Subject) GetUserOrganization {
+	return ExtractorWithParams(s, nil)
+}
+
+// ExtractorWithParams is Extractor reading path parameters through params,
+// for example chi.URLParam.
+func ExtractorWithParams(s middleware.Subject, params func(r *http.Request, name string) string) GetUserOrganization {
+	return func(r *http.Request) (string, string, error) {
+		var p func(string) string
+		if params != nil {
+			p = func(name string) string { return params(r, name) }
+		}
+		return s.Extract(middleware.NewSubjectRequest(r, p))
+	}
+}
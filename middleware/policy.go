@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNoPolicy = errors.New("route has no policy")
+)
+
+// Rule is one entry of a Policy. Method is an HTTP method or empty for any
+// method. Path segments starting with ':' match one segment and a trailing
+// segment starting with '*' matches the rest of the path, as in the gin and
+// fwncs routers: "/admin/*path" matches "/admin/" and "/admin/users" but not
+// "/admin", which the routers redirect to "/admin/". A public rule needs no
+// permissions; otherwise all of Permissions are required, or only one of
+// them when Any is set.
+type Rule struct {
+	Method      string
+	Path        string
+	Permissions []string
+	Any         bool
+	Public      bool
+}
+
+func (r *Rule) String() string {
+	method := r.Method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + r.Path
+}
+
+// Check asks a for the permissions required by the rule, so that the
+// answers are shared with the other checks of the request.
+func (r *Rule) Check(a *Authorizer) error {
+	if r.Public {
+		return nil
+	}
+	if !r.Any {
+		return a.Require(r.Permissions...)
+	}
+	ok, err := a.CanAny(r.Permissions...)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (r *Rule) match(method, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	return matchPath(splitPath(r.Path), splitPath(path), strings.HasSuffix(path, "/"))
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// matchPath matches the segments of a path against those of a pattern. dir
+// reports a trailing slash, which lets a wildcard match an empty rest.
+func matchPath(pattern, path []string, dir bool) bool {
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "*") {
+			return i == len(pattern)-1 && (i < len(path) || dir)
+		}
+		if i >= len(path) {
+			return false
+		}
+		if !strings.HasPrefix(p, ":") && p != path[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(path)
+}
+
+// Route is a route registered on a router, used to validate a Policy.
+type Route struct {
+	Method string
+	Path   string
+}
+
+// UnmappedRoutesError lists the routes a Policy has no rule for.
+type UnmappedRoutesError struct {
+	Routes []Route
+}
+
+func (e *UnmappedRoutesError) Error() string {
+	routes := make([]string, len(e.Routes))
+	for i, r := range e.Routes {
+		routes[i] = r.Method + " " + r.Path
+	}
+	return fmt.Sprintf("policy: %d routes have no rule: %s", len(routes), strings.Join(routes, ", "))
+}
+
+// Policy maps routes to their required permissions. Rules are matched in
+// order and the first match wins, so more specific rules go first.
+type Policy struct {
+	rules       []Rule
+	defaultDeny bool
+}
+
+type PolicyOption func(p *Policy)
+
+// WithDefaultDeny rejects requests no rule matches instead of letting them
+// through.
+func WithDefaultDeny() PolicyOption {
+	return func(p *Policy) {
+		p.defaultDeny = true
+	}
+}
+
+func NewPolicy(rules []Rule, opts ...PolicyOption) (*Policy, error) {
+	for _, r := range rules {
+		if r.Path == "" || r.Path[0] != '/' {
+			return nil, fmt.Errorf("policy: %s: path must start with '/'", r.String())
+		}
+		if !r.Public && len(r.Permissions) == 0 {
+			return nil, fmt.Errorf("policy: %s: rule needs permissions or must be public", r.String())
+		}
+		if r.Public && len(r.Permissions) > 0 {
+			return nil, fmt.Errorf("policy: %s: public rule must not have permissions", r.String())
+		}
+	}
+	p := &Policy{rules: rules}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+func MustNewPolicy(rules []Rule, opts ...PolicyOption) *Policy {
+	p, err := NewPolicy(rules, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Match returns the first rule matching the request.
+func (p *Policy) Match(method, path string) (*Rule, bool) {
+	for i := range p.rules {
+		if p.rules[i].match(method, path) {
+			return &p.rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// Check matches the request and runs the rule's check. authorizer is only
+// called when a non-public rule matched, so that public and unmapped routes
+// need neither a client nor a subject. Without a rule the request is let
+// through, or rejected with ErrNoPolicy in default-deny mode.
+func (p *Policy) Check(method, path string, authorizer func() (*Authorizer, error)) error {
+	rule, ok := p.Match(method, path)
+	if !ok {
+		if p.defaultDeny {
+			return fmt.Errorf("%w: %s %s", ErrNoPolicy, method, path)
+		}
+		return nil
+	}
+	if rule.Public {
+		return nil
+	}
+	a, err := authorizer()
+	if err != nil {
+		return err
+	}
+	return rule.Check(a)
+}
+
+// Unmapped returns the routes no rule matches. Route parameters such as
+// ":id" are matched as literal segments, so a rule for "/users/:id" covers
+// a route registered as "/users/:user_id" but a rule for "/users/me" does
+// not.
+func (p *Policy) Unmapped(routes []Route) []Route {
+	var unmapped []Route
+	for _, r := range routes {
+		if _, ok := p.Match(r.Method, r.Path); !ok {
+			unmapped = append(unmapped, r)
+		}
+	}
+	return unmapped
+}
+
+// Validate returns an *UnmappedRoutesError when any route has no rule.
+func (p *Policy) Validate(routes []Route) error {
+	if unmapped := p.Unmapped(routes); len(unmapped) > 0 {
+		return &UnmappedRoutesError{Routes: unmapped}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("admin:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	client := srv.Client(t)
+
+	rules := []middleware.Rule{
+		{Method: http.MethodGet, Path: "/health", Public: true},
+		{Method: http.MethodGet, Path: "/users/me", Permissions: []string{"admin:test"}},
+		{Method: http.MethodGet, Path: "/users/:id", Permissions: []string{"admin:test", "read:test"}, Any: true},
+		{Path: "/admin/*path", Permissions: []string{"admin:test", "read:test"}},
+	}
+	p, err := middleware.NewPolicy(rules)
+	require.NoError(t, err)
+	as := func(userKey string) func() (*middleware.Authorizer, error) {
+		return func() (*middleware.Authorizer, error) { return middleware.NewAuthorizer(client, userKey, "default"), nil }
+	}
+
+	rule, ok := p.Match(http.MethodGet, "/users/me")
+	require.True(t, ok)
+	assert.Equal(t, "GET /users/me", rule.String())
+	rule, ok = p.Match(http.MethodDelete, "/admin/users/1/")
+	require.True(t, ok)
+	assert.Equal(t, "* /admin/*path", rule.String())
+	_, ok = p.Match(http.MethodPost, "/users/1")
+	assert.False(t, ok)
+	_, ok = p.Match(http.MethodGet, "/admin")
+	assert.False(t, ok)
+	_, ok = p.Match(http.MethodGet, "/admin/")
+	assert.True(t, ok)
+
+	assert.NoError(t, p.Check(http.MethodGet, "/health", nil))
+	assert.NoError(t, p.Check(http.MethodGet, "/users/1", as("user1")))
+	assert.ErrorIs(t, p.Check(http.MethodGet, "/users/1", as("user2")), middleware.ErrForbidden)
+	assert.ErrorIs(t, p.Check(http.MethodGet, "/users/me", as("user1")), middleware.ErrForbidden)
+	assert.ErrorIs(t, p.Check(http.MethodGet, "/admin/users", as("user1")), middleware.ErrForbidden)
+	assert.NoError(t, p.Check(http.MethodPost, "/users/1", nil))
+	extractErr := errors.New("no user")
+	assert.Equal(t, extractErr, p.Check(http.MethodGet, "/users/1", func() (*middleware.Authorizer, error) { return nil, extractErr }))
+
+	p, err = middleware.NewPolicy(rules, middleware.WithDefaultDeny())
+	require.NoError(t, err)
+	assert.ErrorIs(t, p.Check(http.MethodPost, "/users/1", nil), middleware.ErrNoPolicy)
+
+	err = p.Validate([]middleware.Route{
+		{Method: http.MethodGet, Path: "/health"},
+		{Method: http.MethodGet, Path: "/users/:user_id"},
+		{Method: http.MethodPut, Path: "/users/:user_id"},
+		{Method: http.MethodGet, Path: "/admin/*filepath"},
+	})
+	var unmapped *middleware.UnmappedRoutesError
+	require.True(t, errors.As(err, &unmapped))
+	assert.Equal(t, []middleware.Route{{Method: http.MethodPut, Path: "/users/:user_id"}}, unmapped.Routes)
+	assert.EqualError(t, err, "policy: 1 routes have no rule: PUT /users/:user_id")
+
+	_, err = middleware.NewPolicy([]middleware.Rule{{Method: http.MethodGet, Path: "/users"}})
+	assert.EqualError(t, err, "policy: GET /users: rule needs permissions or must be public")
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShadowDenial is a request that would have been rejected had its route not
+// been in shadow mode. Route is the registered route pattern when the router
+// exposes it and the request path otherwise.
+type ShadowDenial struct {
+	Time             time.Time
+	Method           string
+	Route            string
+	UserKey          string
+	OrganizationName string
+	Permissions      []string
+	Failure          *Failure
+}
+
+// ShadowRecorder receives shadow denials, for example to log them or count
+// them in a metrics system. It is called on the request goroutine.
+type ShadowRecorder interface {
+	RecordShadowDenial(d ShadowDenial)
+}
+
+type ShadowRecorderFunc func(d ShadowDenial)
+
+func (f ShadowRecorderFunc) RecordShadowDenial(d ShadowDenial) {
+	f(d)
+}
+
+// LogRecorder logs every shadow denial to logger, or to the standard logger
+// when nil.
+func LogRecorder(logger *log.Logger) ShadowRecorder {
+	return ShadowRecorderFunc(func(d ShadowDenial) {
+		msg := fmt.Sprintf("rbns shadow: would deny %s %s user=%q organization=%q permissions=%q: %s",
+			d.Method, d.Route, d.UserKey, d.OrganizationName, d.Permissions, d.Failure)
+		if logger != nil {
+			logger.Print(msg)
+		} else {
+			log.Print(msg)
+		}
+	})
+}
+
+// Shadow decides which routes run in shadow mode, where the permission check
+// is evaluated but failures are only recorded and the request goes through.
+// Routes follow the global switch unless overridden. It is safe to switch
+// at runtime.
+type Shadow struct {
+	mu        sync.RWMutex
+	enabled   bool
+	routes    map[string]bool
+	recorders []ShadowRecorder
+}
+
+func NewShadow(recorders ...ShadowRecorder) *Shadow {
+	return &Shadow{routes: map[string]bool{}, recorders: recorders}
+}
+
+func shadowRouteKey(method, route string) string {
+	return method + " " + route
+}
+
+// SetEnabled switches shadow mode for every route without an override.
+func (s *Shadow) SetEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = enabled
+}
+
+// SetRoute overrides the global switch for one route.
+func (s *Shadow) SetRoute(method, route string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[shadowRouteKey(method, route)] = enabled
+}
+
+// ResetRoute makes a route follow the global switch again.
+func (s *Shadow) ResetRoute(method, route string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routes, shadowRouteKey(method, route))
+}
+
+func (s *Shadow) Enabled(method, route string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if enabled, ok := s.routes[shadowRouteKey(method, route)]; ok {
+		return enabled
+	}
+	return s.enabled
+}
+
+// Record passes d to every recorder.
+func (s *Shadow) Record(d ShadowDenial) {
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+	for _, r := range s.recorders {
+		r.RecordShadowDenial(d)
+	}
+}
+
+// ShadowSummary aggregates the shadow denials of one route and permission
+// set.
+type ShadowSummary struct {
+	Method      string
+	Route       string
+	Permissions []string
+	Count       int
+	Subjects    int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+}
+
+// DefaultShadowReportSize is the number of denials a ShadowReport keeps.
+const DefaultShadowReportSize = 10000
+
+// ShadowReport is a ShadowRecorder keeping the denials of the last window
+// to summarize them. At most size denials are kept, the oldest dropped
+// first, so that a busy route cannot grow it without bound.
+type ShadowReport struct {
+	mu      sync.Mutex
+	window  time.Duration
+	size    int
+	denials []ShadowDenial
+	now     func() time.Time
+}
+
+func NewShadowReport(window time.Duration) *ShadowReport {
+	return NewShadowReportSize(window, DefaultShadowReportSize)
+}
+
+// NewShadowReportSize is NewShadowReport keeping at most size denials.
+func NewShadowReportSize(window time.Duration, size int) *ShadowReport {
+	if size < 1 {
+		size = 1
+	}
+	return &ShadowReport{window: window, size: size, now: time.Now}
+}
+
+func (r *ShadowReport) RecordShadowDenial(d ShadowDenial) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	if len(r.denials) >= r.size {
+		r.denials = append(r.denials[:0], r.denials[len(r.denials)-r.size+1:]...)
+	}
+	r.denials = append(r.denials, d)
+}
+
+// prune drops the denials older than the window; denials are recorded in
+// time order.
+func (r *ShadowReport) prune() {
+	since := r.now().Add(-r.window)
+	i := sort.Search(len(r.denials), func(i int) bool { return !r.denials[i].Time.Before(since) })
+	r.denials = append(r.denials[:0], r.denials[i:]...)
+}
+
+// Summary groups the denials of the window by route and permissions, most
+// frequent first.
+func (r *ShadowReport) Summary() []ShadowSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	type group struct {
+		summary  ShadowSummary
+		subjects map[string]bool
+	}
+	groups := map[string]*group{}
+	var order []string
+	for _, d := range r.denials {
+		key := d.Method + " " + d.Route + " " + strings.Join(d.Permissions, ",")
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				summary:  ShadowSummary{Method: d.Method, Route: d.Route, Permissions: d.Permissions, FirstSeen: d.Time},
+				subjects: map[string]bool{},
+			}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.summary.Count++
+		g.summary.LastSeen = d.Time
+		g.subjects[d.OrganizationName+"/"+d.UserKey] = true
+	}
+	summaries := make([]ShadowSummary, len(order))
+	for i, key := range order {
+		g := groups[key]
+		g.summary.Subjects = len(g.subjects)
+		summaries[i] = g.summary
+	}
+	sort.SliceStable(summaries, func(i, j int) bool { return summaries[i].Count > summaries[j].Count })
+	return summaries
+}
+
+// WriteText writes the summary as one line per group.
+func (r *ShadowReport) WriteText(w io.Writer) error {
+	summaries := r.Summary()
+	if _, err := fmt.Fprintf(w, "Shadow denials in the last %s:\n", r.window); err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		_, err := fmt.Fprintf(w, "%6d  %s %s [%s] subjects=%d first=%s last=%s\n",
+			s.Count, s.Method, s.Route, strings.Join(s.Permissions, ", "), s.Subjects,
+			s.FirstSeen.Format(time.RFC3339), s.LastSeen.Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
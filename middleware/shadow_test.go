@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadow(t *testing.T) {
+	var recorded []ShadowDenial
+	s := NewShadow(ShadowRecorderFunc(func(d ShadowDenial) { recorded = append(recorded, d) }))
+	assert.False(t, s.Enabled(http.MethodGet, "/users/:id"))
+	s.SetEnabled(true)
+	s.SetRoute(http.MethodDelete, "/users/:id", false)
+	assert.True(t, s.Enabled(http.MethodGet, "/users/:id"))
+	assert.False(t, s.Enabled(http.MethodDelete, "/users/:id"))
+	s.ResetRoute(http.MethodDelete, "/users/:id")
+	assert.True(t, s.Enabled(http.MethodDelete, "/users/:id"))
+
+	s.Record(ShadowDenial{Method: http.MethodGet, Route: "/users/:id"})
+	assert.Len(t, recorded, 1)
+	assert.False(t, recorded[0].Time.IsZero())
+}
+
+func TestShadowReport(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	r := NewShadowReport(time.Hour)
+	r.now = func() time.Time { return now }
+	denial := func(minutes int, route, userKey string, permissionNames ...string) ShadowDenial {
+		return ShadowDenial{
+			Time:             now.Add(time.Duration(minutes) * time.Minute),
+			Method:           http.MethodGet,
+			Route:            route,
+			UserKey:          userKey,
+			OrganizationName: "default",
+			Permissions:      permissionNames,
+		}
+	}
+	r.RecordShadowDenial(denial(-90, "/old", "user1", "read:test"))
+	r.RecordShadowDenial(denial(-30, "/users/:id", "user1", "read:test"))
+	r.RecordShadowDenial(denial(-20, "/docs", "user1", "read:doc"))
+	r.RecordShadowDenial(denial(-10, "/users/:id", "user2", "read:test"))
+	r.RecordShadowDenial(denial(-5, "/users/:id", "user2", "read:test"))
+
+	assert.Equal(t, []ShadowSummary{
+		{Method: http.MethodGet, Route: "/users/:id", Permissions: []string{"read:test"}, Count: 3, Subjects: 2, FirstSeen: now.Add(-30 * time.Minute), LastSeen: now.Add(-5 * time.Minute)},
+		{Method: http.MethodGet, Route: "/docs", Permissions: []string{"read:doc"}, Count: 1, Subjects: 1, FirstSeen: now.Add(-20 * time.Minute), LastSeen: now.Add(-20 * time.Minute)},
+	}, r.Summary())
+
+	now = now.Add(45 * time.Minute)
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteText(&buf))
+	assert.Equal(t, `Shadow denials in the last 1h0m0s:
+     2  GET /users/:id [read:test] subjects=1 first=2021-09-01T11:50:00Z last=2021-09-01T11:55:00Z
+`, buf.String())
+}
+
+func TestShadowReportSize(t *testing.T) {
+	now := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	r := NewShadowReportSize(time.Hour, 2)
+	r.now = func() time.Time { return now }
+	for i, userKey := range []string{"user1", "user2", "user3"} {
+		r.RecordShadowDenial(ShadowDenial{Time: now.Add(time.Duration(i) * time.Minute), Method: http.MethodGet, Route: "/docs", UserKey: userKey})
+	}
+	assert.Equal(t, []ShadowSummary{
+		{Method: http.MethodGet, Route: "/docs", Count: 2, Subjects: 2, FirstSeen: now.Add(time.Minute), LastSeen: now.Add(2 * time.Minute)},
+	}, r.Summary())
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrNoValue = errors.New("value not present")
+)
+
+// SubjectRequest is the request seen by a ValueFunc. Params reads path
+// parameters and may be nil when the router has none.
+type SubjectRequest struct {
+	*http.Request
+	Params func(name string) string
+
+	claims map[*JWTVerifier]claimsResult
+}
+
+type claimsResult struct {
+	claims Claims
+	err    error
+}
+
+func NewSubjectRequest(r *http.Request, params func(name string) string) *SubjectRequest {
+	return &SubjectRequest{Request: r, Params: params}
+}
+
+// Claims returns the claims of the bearer token verified by v. The token is
+// verified once per request however many values read it.
+func (r *SubjectRequest) Claims(v *JWTVerifier) (Claims, error) {
+	if res, ok := r.claims[v]; ok {
+		return res.claims, res.err
+	}
+	var res claimsResult
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		res.claims, res.err = v.Verify(strings.TrimSpace(auth[7:]))
+	} else {
+		res.err = ErrNoValue
+	}
+	if r.claims == nil {
+		r.claims = map[*JWTVerifier]claimsResult{}
+	}
+	r.claims[v] = res
+	return res.claims, res.err
+}
+
+// ValueFunc reads one value from a request. It returns ErrNoValue when the
+// value is absent so that FirstOf can fall back to the next one.
+type ValueFunc func(r *SubjectRequest) (string, error)
+
+func present(v string) (string, error) {
+	if v == "" {
+		return "", ErrNoValue
+	}
+	return v, nil
+}
+
+func Header(name string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		return present(r.Header.Get(name))
+	}
+}
+
+func PathParam(name string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		if r.Params == nil {
+			return "", ErrNoValue
+		}
+		return present(r.Params(name))
+	}
+}
+
+func Query(name string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		return present(r.URL.Query().Get(name))
+	}
+}
+
+func Cookie(name string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		c, err := r.Cookie(name)
+		if err != nil {
+			return "", ErrNoValue
+		}
+		return present(c.Value)
+	}
+}
+
+func Constant(value string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		return present(value)
+	}
+}
+
+// Subdomain reads the first label of the host below domain, for example
+// "acme" for "acme.example.com" and domain "example.com". When mapping is
+// not nil the label is translated through it and unknown labels are absent.
+func Subdomain(domain string, mapping map[string]string) ValueFunc {
+	suffix := "." + strings.TrimPrefix(domain, ".")
+	return func(r *SubjectRequest) (string, error) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return "", ErrNoValue
+		}
+		labels := strings.Split(strings.TrimSuffix(host, suffix), ".")
+		label := labels[len(labels)-1]
+		if mapping != nil {
+			label = mapping[label]
+		}
+		return present(label)
+	}
+}
+
+// Claim reads a string claim, addressed by a dotted path, of the bearer
+// token verified by v. A missing token is absent; an invalid one is an
+// error.
+func Claim(v *JWTVerifier, path string) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		claims, err := r.Claims(v)
+		if err != nil {
+			return "", err
+		}
+		value, ok := claims.Get(path)
+		if !ok {
+			return "", ErrNoValue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("%w: claim %q is not a string", ErrInvalidToken, path)
+		}
+		return present(s)
+	}
+}
+
+// FirstOf tries values in order and returns the first present one. Errors
+// other than ErrNoValue stop the chain.
+func FirstOf(values ...ValueFunc) ValueFunc {
+	return func(r *SubjectRequest) (string, error) {
+		for _, value := range values {
+			v, err := value(r)
+			if !errors.Is(err, ErrNoValue) {
+				return v, err
+			}
+		}
+		return "", ErrNoValue
+	}
+}
+
+// Subject reads the user key and the organization name of a request.
+type Subject struct {
+	UserKey      ValueFunc
+	Organization ValueFunc
+}
+
+func (s Subject) Extract(r *SubjectRequest) (userKey string, organizationName string, err error) {
+	if userKey, err = s.UserKey(r); err != nil {
+		return "", "", fmt.Errorf("user key: %w", err)
+	}
+	if organizationName, err = s.Organization(r); err != nil {
+		return "", "", fmt.Errorf("organization: %w", err)
+	}
+	return userKey, organizationName, nil
+}
@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubject(t *testing.T) {
+	keys := newTestKeys(t)
+	v := middleware.NewJWTVerifier(keys.jwks)
+	s := middleware.Subject{
+		UserKey: middleware.FirstOf(
+			middleware.Claim(v, "sub"),
+			middleware.Header("X-User"),
+			middleware.Cookie("user"),
+			middleware.Query("user"),
+			middleware.PathParam("user"),
+		),
+		Organization: middleware.FirstOf(
+			middleware.Claim(v, "org.name"),
+			middleware.Subdomain("example.com", map[string]string{"acme": "default"}),
+			middleware.Constant("fallback"),
+		),
+	}
+	extract := func(r *http.Request, params map[string]string) (string, string, error) {
+		var p func(string) string
+		if params != nil {
+			p = func(name string) string { return params[name] }
+		}
+		return s.Extract(middleware.NewSubjectRequest(r, p))
+	}
+	assertSubject := func(r *http.Request, params map[string]string, userKey, organizationName string) {
+		u, o, err := extract(r, params)
+		if assert.NoError(t, err) {
+			assert.Equal(t, userKey, u)
+			assert.Equal(t, organizationName, o)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://acme.example.com:8080/?user=user3", nil)
+	r.Header.Set("Authorization", "Bearer "+keys.sign(t, "ES256", "", map[string]interface{}{"sub": "user1", "org": map[string]interface{}{"name": "claimed"}}))
+	r.Header.Set("X-User", "user2")
+	assertSubject(r, nil, "user1", "claimed")
+
+	r.Header.Del("Authorization")
+	assertSubject(r, nil, "user2", "default")
+
+	r.Header.Del("X-User")
+	r.AddCookie(&http.Cookie{Name: "user", Value: "cookie"})
+	assertSubject(r, nil, "cookie", "default")
+
+	r = httptest.NewRequest(http.MethodGet, "http://other.example.com/?user=user3", nil)
+	assertSubject(r, nil, "user3", "fallback")
+
+	r = httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	assertSubject(r, map[string]string{"user": "user4"}, "user4", "fallback")
+
+	_, _, err := extract(r, nil)
+	assert.ErrorIs(t, err, middleware.ErrNoValue)
+	assert.EqualError(t, err, "user key: value not present")
+
+	r.Header.Set("Authorization", "Bearer invalid")
+	r.Header.Set("X-User", "user2")
+	_, _, err = extract(r, nil)
+	assert.ErrorIs(t, err, middleware.ErrInvalidToken)
+}
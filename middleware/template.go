@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"text/template/parse"
+)
+
+var (
+	ErrUnboundTemplate = errors.New("permission template functions are not bound to a request")
+)
+
+var templateFuncNames = map[string]bool{"can": true, "canAll": true, "canAny": true}
+
+// TemplateFuncs are the "can", "canAll" and "canAny" functions to parse
+// templates with. They fail when called; ExecuteTemplate binds them to a
+// request's subject.
+func TemplateFuncs() template.FuncMap {
+	unbound := func(...string) (bool, error) { return false, ErrUnboundTemplate }
+	return template.FuncMap{"can": unbound, "canAll": unbound, "canAny": unbound}
+}
+
+// FuncMap binds the template functions to a. "can" and "canAll" require
+// every permission, "canAny" one of them:
+//
+//	{{if can "delete:doc"}}<button>Delete</button>{{end}}
+func FuncMap(a *Authorizer) template.FuncMap {
+	return template.FuncMap{"can": a.Can, "canAll": a.Can, "canAny": a.CanAny}
+}
+
+// BindTemplate returns a clone of t with the template functions bound to a,
+// having prefetched every permission named by a string literal in the named
+// template and the templates it calls. t must have been parsed with
+// TemplateFuncs and must never be executed itself, as html/template cannot
+// clone an executed template.
+func BindTemplate(t *template.Template, name string, a *Authorizer) (*template.Template, error) {
+	if err := a.Prefetch(TemplatePermissions(t, name)...); err != nil {
+		return nil, err
+	}
+	clone, err := t.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return clone.Funcs(FuncMap(a)), nil
+}
+
+// ExecuteTemplate renders the named template of t for the subject of a.
+func ExecuteTemplate(w io.Writer, t *template.Template, name string, data interface{}, a *Authorizer) error {
+	clone, err := BindTemplate(t, name, a)
+	if err != nil {
+		return err
+	}
+	return clone.ExecuteTemplate(w, name, data)
+}
+
+// TemplatePermissions lists the permission names passed as string literals
+// to the template functions in the named template of t and, transitively,
+// in the templates it calls by a constant name.
+func TemplatePermissions(t *template.Template, name string) []string {
+	seen := map[string]bool{}
+	var names []string
+	visited := map[string]bool{}
+	var visit func(name string)
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(&n.BranchNode)
+		case *parse.RangeNode:
+			walk(&n.BranchNode)
+		case *parse.WithNode:
+			walk(&n.BranchNode)
+		case *parse.BranchNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+			visit(n.Name)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			if id, ok := n.Args[0].(*parse.IdentifierNode); ok && templateFuncNames[id.Ident] {
+				for _, arg := range n.Args[1:] {
+					if s, ok := arg.(*parse.StringNode); ok && !seen[s.Text] {
+						seen[s.Text] = true
+						names = append(names, s.Text)
+					}
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		if tmpl := t.Lookup(name); tmpl != nil && tmpl.Tree != nil {
+			walk(tmpl.Tree.Root)
+		}
+	}
+	visit(name)
+	return names
+}
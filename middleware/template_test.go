@@ -0,0 +1,54 @@
+package middleware_test
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/middleware"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminTemplates = `{{define "page"}}<h1>{{.}}</h1>{{template "actions" .}}{{end}}
+{{define "settings"}}{{if can "admin:settings"}}<a>Settings</a>{{end}}{{end}}
+{{define "actions"}}{{if can "update:doc"}}<button>Edit</button>{{end}}{{if and (canAny "delete:doc" "admin:doc") (canAll "read:doc" "update:doc")}}<button>Delete</button>{{end}}{{with .}}{{end}}{{end}}`
+
+func TestTemplate(t *testing.T) {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("read:doc", "")
+	srv.AddPermission("update:doc", "")
+	srv.AddPermission("delete:doc", "")
+	srv.AddRole("editor", "read:doc", "update:doc")
+	srv.AddRole("admin", "read:doc", "update:doc", "delete:doc")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+	srv.AddUser("default", "user2", "admin")
+	client := srv.Client(t)
+
+	tmpl := template.Must(template.New("").Funcs(middleware.TemplateFuncs()).Parse(adminTemplates))
+	assert.Equal(t, []string{"update:doc", "delete:doc", "admin:doc", "read:doc"}, middleware.TemplatePermissions(tmpl, "page"))
+	assert.Equal(t, []string{"admin:settings"}, middleware.TemplatePermissions(tmpl, "settings"))
+	assert.Empty(t, middleware.TemplatePermissions(tmpl, "missing"))
+
+	render := func(userKey string) string {
+		var buf bytes.Buffer
+		err := middleware.ExecuteTemplate(&buf, tmpl, "page", "docs", middleware.NewAuthorizer(client, userKey, "default"))
+		require.NoError(t, err)
+		return buf.String()
+	}
+	assert.Equal(t, "<h1>docs</h1><button>Edit</button>", render("user1"))
+	assert.Equal(t, "<h1>docs</h1><button>Edit</button><button>Delete</button>", render("user2"))
+	checks := srv.Checks()
+	assert.Equal(t, "<h1>docs</h1>", render("user3"))
+	// Only the permissions of "page" and "actions" are prefetched.
+	assert.Equal(t, checks+4, srv.Checks())
+
+	var buf bytes.Buffer
+	clone, err := tmpl.Clone()
+	require.NoError(t, err)
+	err = clone.ExecuteTemplate(&buf, "page", "docs")
+	assert.ErrorIs(t, err, middleware.ErrUnboundTemplate)
+}
@@ -0,0 +1,243 @@
+package rbns
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Wildcard matches any single part of a permission name. As the last part of
+// a grant it also matches any remaining parts, so read:* covers read:doc and
+// read:doc:comment.
+const Wildcard = "*"
+
+var (
+	ErrInvalidPermissionName = errors.New("invalid permission name")
+)
+
+// PermissionName is a permission of the form action:resource[:sub].
+type PermissionName struct {
+	Action   string
+	Resource string
+	Sub      string
+}
+
+func ParsePermissionName(s string) (PermissionName, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return PermissionName{}, fmt.Errorf("%w %q: want action:resource[:sub]", ErrInvalidPermissionName, s)
+	}
+	for _, part := range parts {
+		if !validPart(part) {
+			return PermissionName{}, fmt.Errorf("%w %q: bad part %q", ErrInvalidPermissionName, s, part)
+		}
+	}
+	n := PermissionName{Action: parts[0], Resource: parts[1]}
+	if len(parts) == 3 {
+		n.Sub = parts[2]
+	}
+	return n, nil
+}
+
+func MustParsePermissionName(s string) PermissionName {
+	n, err := ParsePermissionName(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func ValidatePermissionName(s string) error {
+	_, err := ParsePermissionName(s)
+	return err
+}
+
+func validPart(part string) bool {
+	if part == Wildcard {
+		return true
+	}
+	if part == "" {
+		return false
+	}
+	for _, r := range part {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (n PermissionName) String() string {
+	if n.Sub == "" {
+		return n.Action + ":" + n.Resource
+	}
+	return n.Action + ":" + n.Resource + ":" + n.Sub
+}
+
+func (n PermissionName) IsWildcard() bool {
+	return n.Action == Wildcard || n.Resource == Wildcard || n.Sub == Wildcard
+}
+
+// Matches reports whether the grant n covers name, honoring wildcards in n.
+func (n PermissionName) Matches(name PermissionName) bool {
+	if !matchPart(n.Action, name.Action) {
+		return false
+	}
+	if n.Resource == Wildcard && n.Sub == "" {
+		return true
+	}
+	if !matchPart(n.Resource, name.Resource) {
+		return false
+	}
+	if n.Sub == Wildcard {
+		return true
+	}
+	return n.Sub == name.Sub
+}
+
+func matchPart(grant, part string) bool {
+	return grant == Wildcard || grant == part
+}
+
+// Implications maps an action to the actions it implies, for example
+// manage implying create, read, update and delete. Implications are
+// transitive.
+type Implications map[string][]string
+
+var defaultImplications = Implications{
+	"manage": {"create", "read", "update", "delete"},
+	"update": {"read"},
+}
+
+// DefaultImplications returns a copy of the implication rules used when
+// none are given, to be extended and passed to NewPermissionSet.
+func DefaultImplications() Implications {
+	rules := make(Implications, len(defaultImplications))
+	for action, implied := range defaultImplications {
+		rules[action] = append([]string{}, implied...)
+	}
+	return rules
+}
+
+// expand returns the action and every action it implies.
+func (rules Implications) expand(action string) []string {
+	seen := map[string]bool{action: true}
+	queue := []string{action}
+	for len(queue) > 0 {
+		a := queue[0]
+		queue = queue[1:]
+		for _, implied := range rules[a] {
+			if !seen[implied] {
+				seen[implied] = true
+				queue = append(queue, implied)
+			}
+		}
+	}
+	res := make([]string, 0, len(seen))
+	for a := range seen {
+		res = append(res, a)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Implies reports whether holding grant allows name under the rules.
+func (rules Implications) Implies(grant, name PermissionName) bool {
+	for _, action := range rules.expand(grant.Action) {
+		g := grant
+		g.Action = action
+		if g.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is a set of granted permission names evaluated with wildcard
+// and implication rules. It is the single place the SDK decides locally
+// whether a set of grants allows a permission.
+type PermissionSet struct {
+	rules  Implications
+	grants []PermissionName
+	exact  map[string]bool
+}
+
+// NewPermissionSet builds a set from granted names. Names that do not parse
+// as action:resource[:sub] only ever match themselves exactly.
+func NewPermissionSet(rules Implications, names ...string) *PermissionSet {
+	if rules == nil {
+		rules = defaultImplications
+	}
+	s := &PermissionSet{rules: rules, exact: map[string]bool{}}
+	for _, name := range names {
+		s.Add(name)
+	}
+	return s
+}
+
+func (s *PermissionSet) Add(name string) {
+	if s.exact[name] {
+		return
+	}
+	s.exact[name] = true
+	if n, err := ParsePermissionName(name); err == nil {
+		s.grants = append(s.grants, n)
+	}
+}
+
+func (s *PermissionSet) Allows(name string) bool {
+	return s.Grant(name) != ""
+}
+
+func (s *PermissionSet) AllowsAll(names ...string) bool {
+	for _, name := range names {
+		if !s.Allows(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Grant returns the granted name that allows name, or "" if none does.
+func (s *PermissionSet) Grant(name string) string {
+	if s.exact[name] {
+		return name
+	}
+	n, err := ParsePermissionName(name)
+	if err != nil {
+		return ""
+	}
+	for _, g := range s.grants {
+		if s.rules.Implies(g, n) {
+			return g.String()
+		}
+	}
+	return ""
+}
+
+// Names returns the granted names in sorted order.
+func (s *PermissionSet) Names() []string {
+	names := make([]string, 0, len(s.exact))
+	for name := range s.exact {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CRUD returns the create, read, update and delete permissions of resource.
+func CRUD(resource string) []string {
+	return Actions(resource, "create", "read", "update", "delete")
+}
+
+// Actions returns action:resource for each action.
+func Actions(resource string, actions ...string) []string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = PermissionName{Action: action, Resource: resource}.String()
+	}
+	return names
+}
@@ -0,0 +1,58 @@
+package rbns
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePermissionName(t *testing.T) {
+	n, err := ParsePermissionName("create:test")
+	assert.NoError(t, err)
+	assert.Equal(t, PermissionName{Action: "create", Resource: "test"}, n)
+	n, err = ParsePermissionName("read:doc:comment")
+	assert.NoError(t, err)
+	assert.Equal(t, PermissionName{Action: "read", Resource: "doc", Sub: "comment"}, n)
+	assert.Equal(t, "read:doc:comment", n.String())
+
+	for _, s := range []string{"", "create", "create:", ":test", "a:b:c:d", "create:te st"} {
+		_, err := ParsePermissionName(s)
+		assert.True(t, errors.Is(err, ErrInvalidPermissionName), s)
+	}
+}
+
+func TestPermissionSet(t *testing.T) {
+	set := NewPermissionSet(nil, "read:*", "*:test", "manage:doc", "legacy permission")
+	for name, want := range map[string]bool{
+		"read:anything":       true,
+		"read:doc:comment":    true,
+		"create:test":         true,
+		"create:test:sub":     false,
+		"update:doc":          true,
+		"delete:doc":          true,
+		"delete:other":        false,
+		"legacy permission":   true,
+		"another legacy name": false,
+	} {
+		assert.Equal(t, want, set.Allows(name), name)
+	}
+	assert.Equal(t, "manage:doc", set.Grant("delete:doc"))
+	assert.True(t, set.AllowsAll(CRUD("test")...))
+	assert.False(t, set.AllowsAll(CRUD("other")...))
+
+	rules := Implications{"update": {"read"}}
+	assert.True(t, NewPermissionSet(rules, "update:doc").Allows("read:doc"))
+	assert.False(t, NewPermissionSet(rules, "manage:doc").Allows("read:doc"))
+
+	rules = DefaultImplications()
+	rules["approve"] = []string{"read"}
+	rules["manage"][0] = "approve"
+	assert.True(t, NewPermissionSet(rules, "approve:doc").Allows("read:doc"))
+	assert.False(t, NewPermissionSet(nil, "approve:doc").Allows("read:doc"))
+	assert.True(t, NewPermissionSet(nil, "manage:doc").Allows("create:doc"))
+}
+
+func TestCRUD(t *testing.T) {
+	assert.Equal(t, []string{"create:test", "read:test", "update:test", "delete:test"}, CRUD("test"))
+}
@@ -0,0 +1,427 @@
+// Package permcheck defines an analyzer checking the permission names passed
+// to rbns and the routes registered without a permission check.
+//
+// Permission names are the constant arguments of any parameter named
+// permissionNames or permissions of an rbns function, which covers the
+// PermissionCheck middleware of every router, middleware.PermissionCheck,
+// Client.Check and the Authorizer. Each is reported when it is not a valid
+// action:resource[:sub] name, or when -manifest is set and the manifest does
+// not declare it.
+//
+// A gin or fwncs route is reported when neither its handlers nor the Use or
+// Group calls of the router it was registered on in the same function
+// include a permission check. Routes on routers received from elsewhere are
+// not reported. Mark intentionally public routes with a //rbns:public
+// comment on the same line or the line above.
+package permcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+	"sync"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const (
+	modulePath = "github.com/n-creativesystem/go-rbns"
+	ginPath    = "github.com/gin-gonic/gin"
+	fwncsPath  = "github.com/n-creativesystem/go-fwncs"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "permcheck",
+	Doc:      "check rbns permission names and routes without a permission check",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var manifestPath string
+
+func init() {
+	Analyzer.Flags.StringVar(&manifestPath, "manifest", "", "manifest file declaring the permissions")
+}
+
+var manifests struct {
+	sync.Mutex
+	loaded map[string]map[string]bool
+}
+
+// declared returns the permission names of the manifest at path, loading it
+// once for all packages.
+func declared(path string) (map[string]bool, error) {
+	manifests.Lock()
+	defer manifests.Unlock()
+	if names, ok := manifests.loaded[path]; ok {
+		return names, nil
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(m.Permissions))
+	for _, p := range m.Permissions {
+		names[p.Name] = true
+	}
+	if manifests.loaded == nil {
+		manifests.loaded = map[string]map[string]bool{}
+	}
+	manifests.loaded[path] = names
+	return names, nil
+}
+
+// checkFuncs are the middleware constructors of the router adapters that
+// check permissions.
+var checkFuncs = map[string]bool{
+	"PermissionCheck":                  true,
+	"PermissionCheckWithClientOptions": true,
+	"PermissionCheckWithErrorHandler":  true,
+	"TypedPermissionCheck":             true,
+	"ConditionCheck":                   true,
+	"ExpressionCheck":                  true,
+	"ExpressionCheckWithErrorHandler":  true,
+	"PolicyCheck":                      true,
+	"PolicyCheckWithErrorHandler":      true,
+}
+
+// routeMethods maps the route registering methods of gin and fwncs to the
+// index of their first handler.
+var routeMethods = map[string]int{
+	"GET": 1, "POST": 1, "PUT": 1, "PATCH": 1, "DELETE": 1, "HEAD": 1, "OPTIONS": 1, "Any": 1, "Handle": 2,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var names map[string]bool
+	if manifestPath != "" {
+		var err error
+		if names, err = declared(manifestPath); err != nil {
+			return nil, fmt.Errorf("permcheck: %w", err)
+		}
+	}
+	public := publicLines(pass)
+	wrappers := checkWrappers(pass, public)
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		checkNames(pass, n.(*ast.CallExpr), names)
+	})
+	insp.Preorder([]ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}, func(n ast.Node) {
+		var body *ast.BlockStmt
+		switch f := n.(type) {
+		case *ast.FuncDecl:
+			body = f.Body
+		case *ast.FuncLit:
+			body = f.Body
+		}
+		if body != nil {
+			r := &routes{pass: pass, public: public, wrappers: wrappers, routers: map[types.Object]bool{}, middleware: map[types.Object]bool{}}
+			r.check(body)
+		}
+	})
+	return nil, nil
+}
+
+func callee(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	f, _ := pass.TypesInfo.Uses[id].(*types.Func)
+	return f
+}
+
+func inModule(f *types.Func) bool {
+	if f.Pkg() == nil {
+		return false
+	}
+	path := f.Pkg().Path()
+	return path == modulePath || strings.HasPrefix(path, modulePath+"/")
+}
+
+// checkNames reports the invalid or undeclared constant permission names
+// passed to an rbns function.
+func checkNames(pass *analysis.Pass, call *ast.CallExpr, names map[string]bool) {
+	f := callee(pass, call)
+	if f == nil || !inModule(f) {
+		return
+	}
+	sig := f.Type().(*types.Signature)
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		name := params.At(i).Name()
+		if name != "permissionNames" && name != "permissions" || i >= len(call.Args) {
+			continue
+		}
+		var args []ast.Expr
+		switch {
+		case sig.Variadic() && i == params.Len()-1 && !call.Ellipsis.IsValid():
+			args = call.Args[i:]
+		default:
+			if lit, ok := unparen(call.Args[i]).(*ast.CompositeLit); ok {
+				args = lit.Elts
+			}
+		}
+		for _, arg := range args {
+			checkName(pass, arg, names)
+		}
+	}
+}
+
+func checkName(pass *analysis.Pass, arg ast.Expr, names map[string]bool) {
+	tv, ok := pass.TypesInfo.Types[arg]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	name := constant.StringVal(tv.Value)
+	if err := rbns.ValidatePermissionName(name); err != nil {
+		pass.Reportf(arg.Pos(), "%s", err)
+		return
+	}
+	if names != nil && !names[name] {
+		pass.Reportf(arg.Pos(), "permission %q is not declared in the manifest", name)
+	}
+}
+
+// publicLines collects the lines of the //rbns:public comments of each file.
+func publicLines(pass *analysis.Pass) map[string]map[int]bool {
+	lines := map[string]map[int]bool{}
+	for _, file := range pass.Files {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if strings.HasPrefix(c.Text, "//rbns:public") {
+					pos := pass.Fset.Position(c.Slash)
+					if lines[pos.Filename] == nil {
+						lines[pos.Filename] = map[int]bool{}
+					}
+					lines[pos.Filename][pos.Line] = true
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// checkWrappers finds the functions of the package returning a permission
+// check, such as a helper binding the user extractor.
+func checkWrappers(pass *analysis.Pass, public map[string]map[int]bool) map[types.Object]bool {
+	wrappers := map[types.Object]bool{}
+	r := &routes{pass: pass, public: public, wrappers: wrappers}
+	for changed := true; changed; {
+		changed = false
+		for _, file := range pass.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil {
+					continue
+				}
+				obj := pass.TypesInfo.Defs[fn.Name]
+				if wrappers[obj] {
+					continue
+				}
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					switch n := n.(type) {
+					case *ast.FuncLit:
+						return false
+					case *ast.ReturnStmt:
+						if len(n.Results) == 1 && r.isCheck(n.Results[0]) {
+							wrappers[obj] = true
+							changed = true
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+	return wrappers
+}
+
+// routes follows the routers created in one function body: routers maps each
+// router variable to whether a permission check guards it and middleware
+// holds the variables set to a permission check.
+type routes struct {
+	pass       *analysis.Pass
+	public     map[string]map[int]bool
+	wrappers   map[types.Object]bool
+	routers    map[types.Object]bool
+	middleware map[types.Object]bool
+}
+
+func (r *routes) object(e ast.Expr) types.Object {
+	id, ok := unparen(e).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if obj := r.pass.TypesInfo.Defs[id]; obj != nil {
+		return obj
+	}
+	return r.pass.TypesInfo.Uses[id]
+}
+
+func (r *routes) check(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Checked on its own.
+			return false
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, rhs := range n.Rhs {
+				obj := r.object(n.Lhs[i])
+				if obj == nil {
+					continue
+				}
+				if r.isCheck(rhs) {
+					r.middleware[obj] = true
+				} else if guarded, ok := r.router(rhs); ok {
+					r.routers[obj] = guarded
+				}
+			}
+		case *ast.ValueSpec:
+			for i, rhs := range n.Values {
+				if i >= len(n.Names) {
+					break
+				}
+				obj := r.pass.TypesInfo.Defs[n.Names[i]]
+				if r.isCheck(rhs) {
+					r.middleware[obj] = true
+				} else if guarded, ok := r.router(rhs); ok {
+					r.routers[obj] = guarded
+				}
+			}
+		case *ast.CallExpr:
+			r.call(n)
+		}
+		return true
+	})
+}
+
+func routerPackage(f *types.Func) bool {
+	return f.Pkg() != nil && (f.Pkg().Path() == ginPath || f.Pkg().Path() == fwncsPath)
+}
+
+// isCheck reports whether e is a permission check middleware.
+func (r *routes) isCheck(e ast.Expr) bool {
+	if call, ok := unparen(e).(*ast.CallExpr); ok {
+		f := callee(r.pass, call)
+		if f == nil || f.Pkg() == nil {
+			return false
+		}
+		if r.wrappers[f] {
+			return true
+		}
+		path := f.Pkg().Path()
+		return (path == modulePath+"/middleware/gin" || path == modulePath+"/middleware/fwncs") && checkFuncs[f.Name()]
+	}
+	if obj := r.object(e); obj != nil {
+		return r.middleware[obj]
+	}
+	return false
+}
+
+func (r *routes) anyCheck(args []ast.Expr) bool {
+	for _, arg := range args {
+		if r.isCheck(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// router reports whether e is a router known in this function and whether a
+// permission check guards it.
+func (r *routes) router(e ast.Expr) (guarded, known bool) {
+	e = unparen(e)
+	if obj := r.object(e); obj != nil {
+		guarded, known = r.routers[obj]
+		return guarded, known
+	}
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false, false
+	}
+	f := callee(r.pass, call)
+	if f == nil || !routerPackage(f) {
+		return false, false
+	}
+	switch f.Name() {
+	case "New", "Default":
+		return false, f.Type().(*types.Signature).Recv() == nil
+	case "Group":
+		sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok || len(call.Args) == 0 {
+			return false, false
+		}
+		guarded, known = r.router(sel.X)
+		return guarded || r.anyCheck(call.Args[1:]), known
+	}
+	return false, false
+}
+
+func (r *routes) call(call *ast.CallExpr) {
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	f := callee(r.pass, call)
+	if f == nil || !routerPackage(f) || f.Type().(*types.Signature).Recv() == nil {
+		return
+	}
+	if f.Name() == "Use" {
+		if obj := r.object(sel.X); obj != nil && r.anyCheck(call.Args) {
+			if _, known := r.routers[obj]; known {
+				r.routers[obj] = true
+			}
+		}
+		return
+	}
+	first, ok := routeMethods[f.Name()]
+	if !ok || len(call.Args) <= first {
+		return
+	}
+	guarded, known := r.router(sel.X)
+	if !known || guarded || r.anyCheck(call.Args[first:]) || r.isPublic(call) {
+		return
+	}
+	method := f.Name()
+	if f.Name() == "Handle" {
+		method = constString(r.pass, call.Args[0], "?")
+	}
+	r.pass.Reportf(call.Pos(), "route %s %s has no permission check", method, constString(r.pass, call.Args[first-1], "?"))
+}
+
+func (r *routes) isPublic(call *ast.CallExpr) bool {
+	pos := r.pass.Fset.Position(call.Pos())
+	lines := r.public[pos.Filename]
+	return lines[pos.Line] || lines[pos.Line-1]
+}
+
+func constString(pass *analysis.Pass, e ast.Expr, def string) string {
+	if tv, ok := pass.TypesInfo.Types[e]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return constant.StringVal(tv.Value)
+	}
+	return def
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
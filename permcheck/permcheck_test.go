@@ -0,0 +1,21 @@
+package permcheck_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/permcheck"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestNames(t *testing.T) {
+	testdata := analysistest.TestData()
+	require.NoError(t, permcheck.Analyzer.Flags.Set("manifest", filepath.Join(testdata, "rbns.yaml")))
+	defer permcheck.Analyzer.Flags.Set("manifest", "")
+	analysistest.Run(t, testdata, permcheck.Analyzer, "names")
+}
+
+func TestRoutes(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), permcheck.Analyzer, "routes")
+}
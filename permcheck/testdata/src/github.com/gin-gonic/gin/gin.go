@@ -0,0 +1,19 @@
+package gin
+
+type Context struct{}
+
+type HandlerFunc func(*Context)
+
+type RouterGroup struct{}
+
+func (g *RouterGroup) Use(middleware ...HandlerFunc)                           {}
+func (g *RouterGroup) Group(path string, handlers ...HandlerFunc) *RouterGroup { return g }
+func (g *RouterGroup) GET(path string, handlers ...HandlerFunc)                {}
+func (g *RouterGroup) POST(path string, handlers ...HandlerFunc)               {}
+func (g *RouterGroup) Handle(method, path string, handlers ...HandlerFunc)     {}
+
+type Engine struct {
+	RouterGroup
+}
+
+func New() *Engine { return &Engine{} }
@@ -0,0 +1,13 @@
+package fwncs
+
+type Context interface{}
+
+type HandlerFunc func(Context)
+
+type Router struct{}
+
+func New() *Router { return &Router{} }
+
+func (r *Router) Use(middleware ...HandlerFunc)                        {}
+func (r *Router) Group(path string, middleware ...HandlerFunc) *Router { return r }
+func (r *Router) GET(path string, h ...HandlerFunc)                    {}
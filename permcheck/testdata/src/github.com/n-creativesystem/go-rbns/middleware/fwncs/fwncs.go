@@ -0,0 +1,7 @@
+package fwncs
+
+import "github.com/n-creativesystem/go-fwncs"
+
+type GetUserOrganization func(c fwncs.Context) (string, string, error)
+
+func PermissionCheck(fn GetUserOrganization, permissionNames ...string) fwncs.HandlerFunc { return nil }
@@ -0,0 +1,20 @@
+package gin
+
+import (
+	"github.com/gin-gonic/gin"
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+type GetUserOrganization func(c *gin.Context) (string, string, error)
+
+func PermissionCheck(fn GetUserOrganization, permissionNames ...string) gin.HandlerFunc { return nil }
+
+func PermissionCheckWithClientOptions(fn GetUserOrganization, permissionNames []string, opts ...rbns.Permission) gin.HandlerFunc {
+	return nil
+}
+
+func TypedPermissionCheck(fn GetUserOrganization, permissions ...rbns.Permission) gin.HandlerFunc {
+	return nil
+}
+
+func Subject(fn GetUserOrganization) gin.HandlerFunc { return nil }
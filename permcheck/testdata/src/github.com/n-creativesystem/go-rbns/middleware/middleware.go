@@ -0,0 +1,7 @@
+package middleware
+
+import rbns "github.com/n-creativesystem/go-rbns"
+
+func PermissionCheck(client *rbns.Client, userKey, organizationName string, permissionNames ...string) error {
+	return nil
+}
@@ -0,0 +1,9 @@
+package rbns
+
+type Permission string
+
+type Client struct{}
+
+func (c *Client) Check(userKey, organizationName string, permissionNames ...string) (bool, error) {
+	return false, nil
+}
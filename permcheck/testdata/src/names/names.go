@@ -0,0 +1,24 @@
+package names
+
+import (
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/middleware"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+)
+
+const deleteTest rbns.Permission = "delete:test"
+
+func check(client *rbns.Client, name string, names []string) {
+	rbnsGin.PermissionCheck(nil, "read:test", "create:test")
+	rbnsGin.PermissionCheck(nil, "read:tset")                                           // want `permission "read:tset" is not declared in the manifest`
+	rbnsGin.PermissionCheck(nil, "readtest")                                            // want `invalid permission name "readtest": want action:resource\[:sub\]`
+	rbnsFwncs.PermissionCheck(nil, "read:test", "write:")                               // want `invalid permission name "write:": bad part ""`
+	rbnsGin.PermissionCheckWithClientOptions(nil, []string{"read:test", "update:test"}) // want `permission "update:test" is not declared in the manifest`
+	rbnsGin.TypedPermissionCheck(nil, deleteTest)                                       // want `permission "delete:test" is not declared in the manifest`
+	rbnsGin.PermissionCheck(nil, name)
+	rbnsGin.PermissionCheck(nil, names...)
+	_ = middleware.PermissionCheck(client, "user1", "default", "read:test", "list:test") // want `permission "list:test" is not declared in the manifest`
+	_, _ = client.Check("user1", "default", "Read:test")                                 // want `permission "Read:test" is not declared in the manifest`
+	_, _ = client.Check("read:user", "read:org", "create:test")
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-fwncs"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+)
+
+func handler(c *gin.Context) {}
+
+func ginRoutes() {
+	router := gin.New()
+	router.Use(rbnsGin.Subject(nil))
+	router.GET("/open", handler) // want `route GET /open has no permission check`
+	router.GET("/checked", rbnsGin.PermissionCheck(nil, "read:test"), handler)
+	router.Handle("PATCH", "/handle", handler) // want `route PATCH /handle has no permission check`
+	//rbns:public
+	router.GET("/health", handler)
+	router.GET("/ping", handler) //rbns:public
+
+	canRead := rbnsGin.PermissionCheck(nil, "read:test")
+	router.GET("/variable", canRead, handler)
+
+	api := router.Group("/api", rbnsGin.PermissionCheck(nil, "read:test"))
+	api.GET("/docs", handler)
+	api.Group("/v1").POST("/docs", handler)
+
+	admin := router.Group("/admin")
+	admin.GET("/before", handler) // want `route GET /before has no permission check`
+	admin.Use(rbnsGin.PermissionCheck(nil, "admin:test"))
+	admin.GET("/after", handler)
+}
+
+func registered(group *gin.RouterGroup) {
+	group.GET("/unknown", handler)
+}
+
+func fwncsRoutes() {
+	router := fwncs.New()
+	router.GET("/open", func(c fwncs.Context) {}) // want `route GET /open has no permission check`
+	router.Use(rbnsFwncs.PermissionCheck(nil, "read:test"))
+	router.GET("/checked", func(c fwncs.Context) {})
+}
+
+func requireRead() gin.HandlerFunc {
+	return rbnsGin.PermissionCheck(nil, "read:test")
+}
+
+func wrapped() {
+	router := gin.New()
+	router.GET("/wrapped", requireRead(), handler)
+}
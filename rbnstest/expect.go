@@ -0,0 +1,95 @@
+// Package rbnstest provides assertions on rbns permissions for tests: fluent
+// expectations on a client, a route matrix running a router for a list of
+// users, and the recording and replay of Check traffic.
+package rbnstest
+
+import (
+	"fmt"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+)
+
+// Expectation asserts the permissions of one subject. User and In return a
+// new Expectation, so a chain can be forked:
+//
+//	user1 := rbnstest.Expect(t, client).User("user1").In("default")
+//	user1.Can("create:test", "read:test").Cannot("delete:test")
+type Expectation struct {
+	t                testing.TB
+	checker          rbns.PermissionClient
+	userKey          string
+	organizationName string
+}
+
+// Expect starts an expectation on checker, usually an *rbns.Client.
+func Expect(t testing.TB, checker rbns.PermissionClient) *Expectation {
+	return &Expectation{t: t, checker: checker}
+}
+
+func (e *Expectation) User(userKey string) *Expectation {
+	res := *e
+	res.userKey = userKey
+	return &res
+}
+
+func (e *Expectation) In(organizationName string) *Expectation {
+	res := *e
+	res.organizationName = organizationName
+	return &res
+}
+
+// Can fails the test for each permission the subject does not hold. The
+// permissions are checked one by one so that the failure names the culprit.
+func (e *Expectation) Can(permissionNames ...string) *Expectation {
+	e.t.Helper()
+	for _, name := range permissionNames {
+		e.expect(true, name)
+	}
+	return e
+}
+
+// Cannot fails the test for each permission the subject holds.
+func (e *Expectation) Cannot(permissionNames ...string) *Expectation {
+	e.t.Helper()
+	for _, name := range permissionNames {
+		e.expect(false, name)
+	}
+	return e
+}
+
+// CanAll fails the test unless the subject holds every permission, checked
+// in a single call as the PermissionCheck middleware does.
+func (e *Expectation) CanAll(permissionNames ...string) *Expectation {
+	e.t.Helper()
+	r, err := e.checker.Check(e.userKey, e.organizationName, permissionNames...)
+	switch {
+	case err != nil:
+		e.t.Errorf("%s: check %q: %v", e, permissionNames, err)
+	case !r:
+		e.t.Errorf("%s: want all of %q granted, got denied", e, permissionNames)
+	}
+	return e
+}
+
+func (e *Expectation) expect(want bool, name string) {
+	e.t.Helper()
+	r, err := e.checker.Check(e.userKey, e.organizationName, name)
+	switch {
+	case err != nil:
+		e.t.Errorf("%s: check %q: %v", e, name, err)
+	case r != want:
+		e.t.Errorf("%s: want %q %s, got %s", e, name, verdict(want), verdict(r))
+	}
+}
+
+func verdict(granted bool) string {
+	if granted {
+		return "granted"
+	}
+	return "denied"
+}
+
+func (e *Expectation) String() string {
+	return fmt.Sprintf("user %q in %q", e.userKey, e.organizationName)
+}
@@ -0,0 +1,115 @@
+package rbnstest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"text/tabwriter"
+)
+
+// Identify sets the user of a request. An empty user is anonymous.
+type Identify func(r *http.Request, user string)
+
+// Header identifies users by a request header, left unset for anonymous
+// requests.
+func Header(name string) Identify {
+	return func(r *http.Request, user string) {
+		if user != "" {
+			r.Header.Set(name, user)
+		}
+	}
+}
+
+type matrixRoute struct {
+	method, path string
+	body         []byte
+	want         []int
+}
+
+// Matrix requests every route as every user and compares the status codes
+// with the expected ones. Gin engines and fwncs routers are both
+// http.Handlers.
+//
+//	m := rbnstest.NewMatrix(router, rbnstest.Header("X-User"), "user1", "user2", "")
+//	m.Route(http.MethodGet, "/api/users/1", 200, 200, 401)
+//	m.Route(http.MethodDelete, "/api/users/1", 200, 403, 401)
+//	m.Run(t)
+type Matrix struct {
+	handler  http.Handler
+	identify Identify
+	users    []string
+	routes   []matrixRoute
+}
+
+func NewMatrix(handler http.Handler, identify Identify, users ...string) *Matrix {
+	return &Matrix{handler: handler, identify: identify, users: users}
+}
+
+// Route adds a route with the status expected for each user, in the order
+// of the users.
+func (m *Matrix) Route(method, path string, want ...int) *Matrix {
+	return m.RouteWithBody(method, path, nil, want...)
+}
+
+func (m *Matrix) RouteWithBody(method, path string, body []byte, want ...int) *Matrix {
+	m.routes = append(m.routes, matrixRoute{method: method, path: path, body: body, want: want})
+	return m
+}
+
+func (m *Matrix) status(route matrixRoute, user string) int {
+	var body io.Reader
+	if route.body != nil {
+		body = bytes.NewReader(route.body)
+	}
+	r := httptest.NewRequest(route.method, route.path, body)
+	if m.identify != nil {
+		m.identify(r, user)
+	}
+	w := httptest.NewRecorder()
+	m.handler.ServeHTTP(w, r)
+	return w.Result().StatusCode
+}
+
+// Run sends every request and fails the test with the whole table when any
+// status differs, each mismatch showing the wanted status.
+func (m *Matrix) Run(t testing.TB) {
+	t.Helper()
+	var (
+		buf      bytes.Buffer
+		failures int
+	)
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprint(w, "ROUTE")
+	for _, user := range m.users {
+		if user == "" {
+			user = "(anonymous)"
+		}
+		fmt.Fprintf(w, "\t%s", user)
+	}
+	fmt.Fprintln(w)
+	for _, route := range m.routes {
+		if len(route.want) != len(m.users) {
+			t.Fatalf("route %s %s: %d statuses for %d users", route.method, route.path, len(route.want), len(m.users))
+			return
+		}
+		fmt.Fprintf(w, "%s %s", route.method, route.path)
+		for i, user := range m.users {
+			got := m.status(route, user)
+			if got == route.want[i] {
+				fmt.Fprintf(w, "\t%d", got)
+			} else {
+				failures++
+				fmt.Fprintf(w, "\t%d (want %d)", got, route.want[i])
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	_ = w.Flush()
+	if failures > 0 {
+		t.Errorf("route matrix: %d of %d requests returned an unexpected status\n%s",
+			failures, len(m.routes)*len(m.users), buf.String())
+	}
+}
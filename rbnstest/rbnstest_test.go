@@ -0,0 +1,109 @@
+package rbnstest_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/n-creativesystem/go-fwncs"
+	rbns "github.com/n-creativesystem/go-rbns"
+	rbnsFwncs "github.com/n-creativesystem/go-rbns/middleware/fwncs"
+	rbnsGin "github.com/n-creativesystem/go-rbns/middleware/gin"
+	"github.com/n-creativesystem/go-rbns/rbnstest"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects the failures of an assertion under test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func newClient(t *testing.T) *rbns.Client {
+	srv := tests.NewServer()
+	t.Cleanup(srv.Close)
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("editor", "create:test", "read:test")
+	srv.AddRole("viewer", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+	srv.AddUser("default", "user2", "viewer")
+	return srv.Client(t)
+}
+
+func TestExpect(t *testing.T) {
+	client := newClient(t)
+	rbnstest.Expect(t, client).User("user1").In("default").
+		Can("create:test", "read:test").CanAll("create:test", "read:test").Cannot("delete:test")
+
+	r := &recorder{}
+	user2 := rbnstest.Expect(r, client).In("default").User("user2")
+	user2.Can("read:test", "create:test").Cannot("read:test", "delete:test").CanAll("read:test", "delete:test")
+	assert.Equal(t, []string{
+		`user "user2" in "default": want "create:test" granted, got denied`,
+		`user "user2" in "default": want "read:test" denied, got granted`,
+		`user "user2" in "default": want all of ["read:test" "delete:test"] granted, got denied`,
+	}, r.errors)
+}
+
+func getUser(user string) (string, string, error) {
+	return user, "default", nil
+}
+
+func TestMatrix(t *testing.T) {
+	client := newClient(t)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	fromHeader := func(c *gin.Context) (string, string, error) { return getUser(c.GetHeader("X-User")) }
+	router := gin.New()
+	api := router.Group("/api", rbnsGin.Client(client))
+	api.GET("/docs", rbnsGin.PermissionCheck(fromHeader, "read:test"), ok)
+	api.POST("/docs", rbnsGin.PermissionCheck(fromHeader, "create:test"), ok)
+
+	rbnstest.NewMatrix(router, rbnstest.Header("X-User"), "user1", "user2", "user3").
+		Route(http.MethodGet, "/api/docs", 200, 200, 403).
+		Route(http.MethodPost, "/api/docs", 200, 403, 403).
+		Run(t)
+
+	r := &recorder{}
+	rbnstest.NewMatrix(router, rbnstest.Header("X-User"), "user1", "").
+		Route(http.MethodGet, "/api/docs", 200, 200).
+		Route(http.MethodPost, "/api/docs", 403, 403).
+		Run(r)
+	require.Len(t, r.errors, 1)
+	lines := strings.Split(strings.TrimSpace(r.errors[0]), "\n")
+	assert.Equal(t, []string{
+		"route matrix: 2 of 4 requests returned an unexpected status",
+		"ROUTE           user1           (anonymous)",
+		"GET /api/docs   200             403 (want 200)",
+		"POST /api/docs  200 (want 403)  403",
+	}, lines)
+}
+
+func TestMatrixFwncs(t *testing.T) {
+	client := newClient(t)
+	router := fwncs.New()
+	router.Use(rbnsFwncs.Client(client))
+	router.DELETE("/api/docs", rbnsFwncs.PermissionCheck(func(c fwncs.Context) (string, string, error) {
+		return getUser(c.Header().Get("X-User"))
+	}, "delete:test"), func(c fwncs.Context) { c.AbortWithStatus(http.StatusNoContent) })
+
+	rbnstest.NewMatrix(router, rbnstest.Header("X-User"), "user1", "user2").
+		Route(http.MethodDelete, "/api/docs", 403, 403).
+		Run(t)
+}
@@ -0,0 +1,306 @@
+package rbnstest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	checkMethod  = "/ncs.protobuf.Permission/Check"
+	healthMethod = "/grpc.health.v1.Health/Check"
+
+	goldenVersion = 1
+)
+
+// CheckRecord is one recorded Permission.Check call. Code and Message are
+// set when the call failed.
+type CheckRecord struct {
+	UserKey          string   `json:"userKey"`
+	OrganizationName string   `json:"organizationName"`
+	PermissionNames  []string `json:"permissionNames"`
+	Result           bool     `json:"result"`
+	Code             string   `json:"code,omitempty"`
+	Message          string   `json:"message,omitempty"`
+}
+
+func (c CheckRecord) String() string {
+	return fmt.Sprintf("Check(%q, %q, %q)", c.UserKey, c.OrganizationName, c.PermissionNames)
+}
+
+func (c CheckRecord) key() string {
+	names := append([]string{}, c.PermissionNames...)
+	sort.Strings(names)
+	return c.UserKey + "\x00" + c.OrganizationName + "\x00" + strings.Join(names, "\x00")
+}
+
+func (c CheckRecord) exact(other CheckRecord) bool {
+	if c.UserKey != other.UserKey || c.OrganizationName != other.OrganizationName || len(c.PermissionNames) != len(other.PermissionNames) {
+		return false
+	}
+	for i, name := range c.PermissionNames {
+		if other.PermissionNames[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+func (c CheckRecord) response() (bool, error) {
+	if c.Code == "" {
+		return c.Result, nil
+	}
+	code := codes.Unknown
+	for i := codes.OK; i <= codes.Unauthenticated; i++ {
+		if i.String() == c.Code {
+			code = i
+		}
+	}
+	return false, status.Error(code, c.Message)
+}
+
+type golden struct {
+	Version int           `json:"version"`
+	Checks  []CheckRecord `json:"checks"`
+}
+
+// Recorder captures the Check traffic of a client, through its interceptor
+// or by wrapping a PermissionClient, to replay it later.
+//
+//	rec := rbnstest.NewRecorder()
+//	client, err := rbns.Connection(ctx, rbns.WithHost(staging),
+//		rbns.WithDialOption(grpc.WithUnaryInterceptor(rec.UnaryClientInterceptor())))
+//	...
+//	err = rec.Save("testdata/checks.golden.json")
+type Recorder struct {
+	mu      sync.Mutex
+	records []CheckRecord
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) record(userKey, organizationName string, permissionNames []string, result bool, err error) {
+	rec := CheckRecord{
+		UserKey:          userKey,
+		OrganizationName: organizationName,
+		PermissionNames:  append([]string{}, permissionNames...),
+		Result:           result,
+	}
+	if err != nil {
+		s := status.Convert(err)
+		rec.Result = false
+		rec.Code = s.Code().String()
+		rec.Message = s.Message()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// UnaryClientInterceptor records every Permission.Check call going through
+// the connection.
+func (r *Recorder) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if method == checkMethod {
+			in := req.(*proto.PermissionCheckRequest)
+			r.record(in.GetUserKey(), in.GetOrganizationName(), in.GetPermissionNames(), reply.(*proto.PermissionCheckResult).GetResult(), err)
+		}
+		return err
+	}
+}
+
+type recordingClient struct {
+	r    *Recorder
+	next rbns.PermissionClient
+}
+
+func (c *recordingClient) Check(userKey, organizationName string, permissionNames ...string) (bool, error) {
+	result, err := c.next.Check(userKey, organizationName, permissionNames...)
+	c.r.record(userKey, organizationName, permissionNames, result, err)
+	return result, err
+}
+
+// Authorizer wraps next to record its checks.
+func (r *Recorder) Authorizer(next rbns.PermissionClient) rbns.PermissionClient {
+	return &recordingClient{r: r, next: next}
+}
+
+func (r *Recorder) Records() []CheckRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CheckRecord{}, r.records...)
+}
+
+// Save writes the recorded checks to a golden file.
+func (r *Recorder) Save(path string) error {
+	buf, err := json.MarshalIndent(golden{Version: goldenVersion, Checks: r.Records()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(buf, '\n'), 0o644)
+}
+
+// MatchMode decides how a Replayer pairs calls with recordings.
+type MatchMode int
+
+const (
+	// Strict serves the recordings in their order, each once, and requires
+	// every call to match the next recording exactly.
+	Strict MatchMode = iota
+	// Lenient serves any recording of the same subject and permission set,
+	// in any order and as often as needed. Several recordings of one call
+	// are served in turn, repeating the last one.
+	Lenient
+)
+
+// Replayer answers checks from recordings. Calls without a matching
+// recording fail and are reported by Unexpected and Verify, as are the
+// recordings never served.
+type Replayer struct {
+	mode    MatchMode
+	records []CheckRecord
+
+	mu         sync.Mutex
+	next       int
+	used       []bool
+	served     map[string]int
+	unexpected []CheckRecord
+}
+
+func NewReplayer(records []CheckRecord, mode MatchMode) *Replayer {
+	return &Replayer{
+		mode:    mode,
+		records: records,
+		used:    make([]bool, len(records)),
+		served:  map[string]int{},
+	}
+}
+
+// LoadReplayer reads a golden file written by Recorder.Save.
+func LoadReplayer(path string, mode MatchMode) (*Replayer, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var g golden
+	if err := json.Unmarshal(buf, &g); err != nil {
+		return nil, fmt.Errorf("rbnstest: %s: %w", path, err)
+	}
+	if g.Version != goldenVersion {
+		return nil, fmt.Errorf("rbnstest: %s: unsupported version %d", path, g.Version)
+	}
+	return NewReplayer(g.Checks, mode), nil
+}
+
+func (r *Replayer) Check(userKey, organizationName string, permissionNames ...string) (bool, error) {
+	call := CheckRecord{UserKey: userKey, OrganizationName: organizationName, PermissionNames: permissionNames}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.match(call)
+	if i < 0 {
+		r.unexpected = append(r.unexpected, call)
+		return false, status.Errorf(codes.NotFound, "rbnstest: no recording for %s", call)
+	}
+	r.used[i] = true
+	return r.records[i].response()
+}
+
+func (r *Replayer) match(call CheckRecord) int {
+	if r.mode == Strict {
+		if r.next < len(r.records) && r.records[r.next].exact(call) {
+			r.next++
+			return r.next - 1
+		}
+		return -1
+	}
+	key := call.key()
+	var candidates []int
+	for i, rec := range r.records {
+		if rec.key() == key {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	n := r.served[key]
+	r.served[key] = n + 1
+	if n >= len(candidates) {
+		n = len(candidates) - 1
+	}
+	return candidates[n]
+}
+
+// UnaryClientInterceptor answers Permission.Check from the recordings and
+// the health check as serving, so that a client needs no server. Other
+// methods fail with Unimplemented.
+func (r *Replayer) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		switch method {
+		case checkMethod:
+			in := req.(*proto.PermissionCheckRequest)
+			result, err := r.Check(in.GetUserKey(), in.GetOrganizationName(), in.GetPermissionNames()...)
+			reply.(*proto.PermissionCheckResult).Result = result
+			return err
+		case healthMethod:
+			reply.(*healthpb.HealthCheckResponse).Status = healthpb.HealthCheckResponse_SERVING
+			return nil
+		default:
+			return status.Errorf(codes.Unimplemented, "rbnstest: %s is not replayed", method)
+		}
+	}
+}
+
+// Connection returns a client served by the replayer.
+func (r *Replayer) Connection(ctx context.Context, opts ...rbns.Option) (*rbns.Client, error) {
+	return rbns.Connection(ctx, append(opts,
+		rbns.WithHost("rbnstest-replay"),
+		rbns.WithDialOption(grpc.WithInsecure(), grpc.WithUnaryInterceptor(r.UnaryClientInterceptor())),
+	)...)
+}
+
+// Unexpected returns the calls that matched no recording.
+func (r *Replayer) Unexpected() []CheckRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CheckRecord{}, r.unexpected...)
+}
+
+// Unused returns the recordings never served.
+func (r *Replayer) Unused() []CheckRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []CheckRecord
+	for i, rec := range r.records {
+		if !r.used[i] {
+			unused = append(unused, rec)
+		}
+	}
+	return unused
+}
+
+// Verify fails the test for every unexpected call and unused recording.
+func (r *Replayer) Verify(t testing.TB) {
+	t.Helper()
+	for _, call := range r.Unexpected() {
+		t.Errorf("rbnstest: unexpected %s", call)
+	}
+	for _, rec := range r.Unused() {
+		t.Errorf("rbnstest: unused recording %s", rec)
+	}
+}
@@ -0,0 +1,97 @@
+package rbnstest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/rbnstest"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func record(t *testing.T) string {
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddRole("editor", "create:test", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "editor")
+
+	rec := rbnstest.NewRecorder()
+	client := srv.Client(t, rbns.WithDialOption(grpc.WithUnaryInterceptor(rec.UnaryClientInterceptor())))
+	for _, perms := range [][]string{{"read:test"}, {"read:test", "create:test"}, {"delete:test"}} {
+		_, err := client.Check("user1", "default", perms...)
+		require.NoError(t, err)
+	}
+	require.Len(t, rec.Records(), 3)
+	path := filepath.Join(t.TempDir(), "checks.golden.json")
+	require.NoError(t, rec.Save(path))
+	return path
+}
+
+func TestReplayStrict(t *testing.T) {
+	path := record(t)
+	replay, err := rbnstest.LoadReplayer(path, rbnstest.Strict)
+	require.NoError(t, err)
+	client, err := replay.Connection(context.Background())
+	require.NoError(t, err)
+	defer client.Close()
+
+	rbnstest.Expect(t, client).User("user1").In("default").Can("read:test").CanAll("read:test", "create:test").Cannot("delete:test")
+	replay.Verify(t)
+
+	replay, err = rbnstest.LoadReplayer(path, rbnstest.Strict)
+	require.NoError(t, err)
+	_, err = replay.Check("user1", "default", "create:test", "read:test")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	ok, err := replay.Check("user1", "default", "read:test")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	r := &recorder{}
+	replay.Verify(r)
+	assert.Equal(t, []string{
+		`rbnstest: unexpected Check("user1", "default", ["create:test" "read:test"])`,
+		`rbnstest: unused recording Check("user1", "default", ["read:test" "create:test"])`,
+		`rbnstest: unused recording Check("user1", "default", ["delete:test"])`,
+	}, r.errors)
+}
+
+func TestReplayLenient(t *testing.T) {
+	replay, err := rbnstest.LoadReplayer(record(t), rbnstest.Lenient)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		rbnstest.Expect(t, replay).User("user1").In("default").Cannot("delete:test").CanAll("create:test", "read:test")
+	}
+	_, err = replay.Check("user2", "default", "read:test")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Len(t, replay.Unexpected(), 1)
+	assert.Equal(t, []rbnstest.CheckRecord{{UserKey: "user1", OrganizationName: "default", PermissionNames: []string{"read:test"}, Result: true}}, replay.Unused())
+}
+
+type failingClient struct{}
+
+func (failingClient) Check(userKey, organizationName string, permissionNames ...string) (bool, error) {
+	return false, status.Error(codes.Unavailable, "down")
+}
+
+func TestRecordErrors(t *testing.T) {
+	rec := rbnstest.NewRecorder()
+	_, err := rec.Authorizer(failingClient{}).Check("user1", "default", "read:test")
+	require.Error(t, err)
+	assert.Equal(t, []rbnstest.CheckRecord{{
+		UserKey: "user1", OrganizationName: "default", PermissionNames: []string{"read:test"},
+		Code: "Unavailable", Message: "down",
+	}}, rec.Records())
+
+	_, err = rbnstest.NewReplayer(rec.Records(), rbnstest.Strict).Check("user1", "default", "read:test")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, "down", status.Convert(err).Message())
+}
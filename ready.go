@@ -0,0 +1,138 @@
+package rbns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var (
+	ErrNotReady = errors.New("rbns client is not ready")
+)
+
+const (
+	healthCheckTimeout = 5 * time.Second
+	minRetryInterval   = 100 * time.Millisecond
+	maxRetryInterval   = 5 * time.Second
+)
+
+// WithNonBlocking makes Connection return without failing when the server
+// is down. The client keeps health checking in the background and calls
+// made before the first successful check fail with ErrNotReady. Connection
+// still waits up to wait for the client to become ready, so that a healthy
+// server is usable right away.
+func WithNonBlocking(wait time.Duration) Option {
+	return func(conf *config) {
+		conf.nonBlocking = true
+		conf.startupWait = wait
+	}
+}
+
+func healthCheck(ctx context.Context, con *grpc.ClientConn) error {
+	resp, err := healthpb.NewHealthClient(con).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: "",
+	})
+	if err != nil {
+		return fmt.Errorf("Status RPC failure: %s", err.Error())
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("Status unhealthy: %s", resp.GetStatus().String())
+	}
+	return nil
+}
+
+// readiness tracks the background health checks of a non-blocking client.
+type readiness struct {
+	ready    chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+func newReadiness() *readiness {
+	return &readiness{ready: make(chan struct{}), stop: make(chan struct{})}
+}
+
+func (r *readiness) run(ctx context.Context, con *grpc.ClientConn) {
+	interval := minRetryInterval
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := healthCheck(checkCtx, con)
+		cancel()
+		if err == nil {
+			close(r.ready)
+			return
+		}
+		r.mu.Lock()
+		r.lastErr = err
+		r.mu.Unlock()
+		timer := time.NewTimer(interval)
+		select {
+		case <-r.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+func (r *readiness) err() error {
+	select {
+	case <-r.ready:
+		return nil
+	default:
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastErr == nil {
+		return ErrNotReady
+	}
+	return fmt.Errorf("%w: %s", ErrNotReady, r.lastErr)
+}
+
+func (r *readiness) close() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+var closedReady = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// Ready is closed once the client passed its health check, which is at
+// creation unless it was created with WithNonBlocking.
+func (c *Client) Ready() <-chan struct{} {
+	if c.readiness == nil {
+		return closedReady
+	}
+	return c.readiness.ready
+}
+
+// WaitReady waits for the client to be ready, returning an error wrapping
+// ErrNotReady when ctx ends first.
+func (c *Client) WaitReady(ctx context.Context) error {
+	select {
+	case <-c.Ready():
+		return nil
+	case <-ctx.Done():
+		return c.readyErr()
+	}
+}
+
+func (c *Client) readyErr() error {
+	if c.readiness == nil {
+		return nil
+	}
+	return c.readiness.err()
+}
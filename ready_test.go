@@ -0,0 +1,85 @@
+package rbns_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func readyServer() *tests.Server {
+	srv := tests.NewServer()
+	srv.AddPermission("read:test", "")
+	srv.AddRole("reader", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "reader")
+	return srv
+}
+
+func TestConnectionBlocking(t *testing.T) {
+	srv := readyServer()
+	defer srv.Close()
+	srv.Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	_, err := rbns.Connection(context.Background(), rbns.WithHost(srv.Addr()), rbns.WithDialOption(srv.DialOptions()...))
+	assert.EqualError(t, err, "Status unhealthy: NOT_SERVING")
+}
+
+func TestConnectionNonBlocking(t *testing.T) {
+	srv := readyServer()
+	defer srv.Close()
+	srv.Health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	client := srv.Client(t, rbns.WithNonBlocking(0))
+	waitFor(t, func() bool { return rbns.ReadyErr(client) != rbns.ErrNotReady })
+	_, err := client.Check("user1", "default", "read:test")
+	assert.True(t, errors.Is(err, rbns.ErrNotReady))
+	assert.EqualError(t, err, "rbns client is not ready: Status unhealthy: NOT_SERVING")
+	_, err = client.EffectivePermissions(context.Background(), "user1", "default")
+	assert.True(t, errors.Is(err, rbns.ErrNotReady))
+	_, err = client.WhoCan(context.Background(), "default", "read:test")
+	assert.True(t, errors.Is(err, rbns.ErrNotReady))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(client.WaitReady(ctx), rbns.ErrNotReady))
+
+	srv.Health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	require.NoError(t, client.WaitReady(context.Background()))
+	ok, err := client.Check("user1", "default", "read:test")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestConnectionStartupWait(t *testing.T) {
+	srv := readyServer()
+	defer srv.Close()
+	client := srv.Client(t, rbns.WithNonBlocking(5*time.Second))
+	select {
+	case <-client.Ready():
+	default:
+		t.Fatal("client not ready after the startup wait")
+	}
+	ok, err := client.Check("user1", "default", "read:test")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestConnectionNonBlockingClose(t *testing.T) {
+	srv := readyServer()
+	srv.Close()
+	client, err := rbns.Connection(context.Background(), rbns.WithHost(srv.Addr()), rbns.WithDialOption(srv.DialOptions()...), rbns.WithNonBlocking(50*time.Millisecond))
+	require.NoError(t, err)
+	_, err = client.Check("user1", "default", "read:test")
+	assert.True(t, errors.Is(err, rbns.ErrNotReady))
+	require.NoError(t, client.Close())
+	// The background health checks stop with the client.
+	if !rbns.HealthChecksStopped(client) {
+		t.Fatal("health checks not stopped")
+	}
+}
@@ -0,0 +1,313 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Diff is the access change between two snapshots. Either snapshot may come
+// from ReadFile or from Export against a live server.
+type Diff struct {
+	Permissions     Changes                `json:"permissions"`
+	Roles           Changes                `json:"roles"`
+	Organizations   Changes                `json:"organizations"`
+	RolePermissions []RolePermissionChange `json:"rolePermissions"`
+	Bindings        []BindingChange        `json:"bindings"`
+	Effective       []EffectiveChange      `json:"effective"`
+}
+
+type Changes struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// RolePermissionChange lists the permissions granted to or revoked from a
+// role that exists in both snapshots.
+type RolePermissionChange struct {
+	Role    string   `json:"role"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// BindingChange lists the roles bound to or unbound from a user.
+type BindingChange struct {
+	Organization string   `json:"organization"`
+	User         string   `json:"user"`
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+}
+
+// EffectiveChange lists the permissions a user gained or lost through any
+// combination of role, binding and direct permission changes.
+type EffectiveChange struct {
+	Organization string   `json:"organization"`
+	User         string   `json:"user"`
+	Granted      []string `json:"granted"`
+	Revoked      []string `json:"revoked"`
+}
+
+// Compare reports what changed from before to after.
+func Compare(before, after *Snapshot) *Diff {
+	d := &Diff{
+		Permissions:     compareNames(permissionNames(before), permissionNames(after)),
+		Roles:           compareNames(roleNames(before), roleNames(after)),
+		Organizations:   compareNames(organizationNames(before), organizationNames(after)),
+		RolePermissions: []RolePermissionChange{},
+		Bindings:        []BindingChange{},
+		Effective:       []EffectiveChange{},
+	}
+
+	beforeRoles := rolePermissions(before)
+	afterRoles := rolePermissions(after)
+	for _, role := range sortedKeys(afterRoles) {
+		prev, ok := beforeRoles[role]
+		if !ok {
+			continue
+		}
+		if c := compareNames(prev, afterRoles[role]); !c.Empty() {
+			d.RolePermissions = append(d.RolePermissions, RolePermissionChange{Role: role, Added: c.Added, Removed: c.Removed})
+		}
+	}
+
+	beforeUsers := users(before)
+	afterUsers := users(after)
+	keys := map[userKey]bool{}
+	for k := range beforeUsers {
+		keys[k] = true
+	}
+	for k := range afterUsers {
+		keys[k] = true
+	}
+	sorted := make([]userKey, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].organization != sorted[j].organization {
+			return sorted[i].organization < sorted[j].organization
+		}
+		return sorted[i].user < sorted[j].user
+	})
+	for _, k := range sorted {
+		prev, next := beforeUsers[k], afterUsers[k]
+		if c := compareNames(prev.Roles, next.Roles); !c.Empty() {
+			d.Bindings = append(d.Bindings, BindingChange{Organization: k.organization, User: k.user, Added: c.Added, Removed: c.Removed})
+		}
+		c := compareNames(effective(prev, beforeRoles), effective(next, afterRoles))
+		if !c.Empty() {
+			d.Effective = append(d.Effective, EffectiveChange{Organization: k.organization, User: k.user, Granted: c.Added, Revoked: c.Removed})
+		}
+	}
+	return d
+}
+
+func (d *Diff) Empty() bool {
+	return d.Permissions.Empty() && d.Roles.Empty() && d.Organizations.Empty() &&
+		len(d.RolePermissions) == 0 && len(d.Bindings) == 0 && len(d.Effective) == 0
+}
+
+func (d *Diff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteText writes the diff as plain text, one change per line.
+func (d *Diff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	if d.Empty() {
+		b.WriteString("No access changes.\n")
+	}
+	writeChanges := func(kind string, c Changes) {
+		for _, name := range c.Added {
+			fmt.Fprintf(&b, "+ %s %s\n", kind, name)
+		}
+		for _, name := range c.Removed {
+			fmt.Fprintf(&b, "- %s %s\n", kind, name)
+		}
+	}
+	writeChanges("permission", d.Permissions)
+	writeChanges("role", d.Roles)
+	writeChanges("organization", d.Organizations)
+	for _, c := range d.RolePermissions {
+		writeChanges(fmt.Sprintf("role %s permission", c.Role), Changes{Added: c.Added, Removed: c.Removed})
+	}
+	for _, c := range d.Bindings {
+		writeChanges(fmt.Sprintf("organization %s user %s role", c.Organization, c.User), Changes{Added: c.Added, Removed: c.Removed})
+	}
+	for _, c := range d.Effective {
+		writeChanges(fmt.Sprintf("organization %s user %s can", c.Organization, c.User), Changes{Added: c.Granted, Removed: c.Revoked})
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the diff as markdown suitable for a pull request
+// comment.
+func (d *Diff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## Access changes\n\n")
+	if d.Empty() {
+		b.WriteString("No access changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	writeList := func(title string, c Changes) {
+		if c.Empty() {
+			return
+		}
+		fmt.Fprintf(&b, "### %s\n\n", title)
+		for _, name := range c.Added {
+			fmt.Fprintf(&b, "- :heavy_plus_sign: `%s`\n", name)
+		}
+		for _, name := range c.Removed {
+			fmt.Fprintf(&b, "- :heavy_minus_sign: `%s`\n", name)
+		}
+		b.WriteString("\n")
+	}
+	writeList("Permissions", d.Permissions)
+	writeList("Roles", d.Roles)
+	writeList("Organizations", d.Organizations)
+	writeTable := func(title string, header string, rows [][]string) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "### %s\n\n%s\n", title, header)
+		cols := strings.Count(header, "|") - 1
+		b.WriteString(strings.Repeat("| --- ", cols) + "|\n")
+		for _, row := range rows {
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+		b.WriteString("\n")
+	}
+	var rows [][]string
+	for _, c := range d.RolePermissions {
+		rows = append(rows, []string{code(c.Role), codes(c.Added), codes(c.Removed)})
+	}
+	writeTable("Role permissions", "| Role | Added | Removed |", rows)
+	rows = nil
+	for _, c := range d.Bindings {
+		rows = append(rows, []string{code(c.Organization), code(c.User), codes(c.Added), codes(c.Removed)})
+	}
+	writeTable("Role bindings", "| Organization | User | Added | Removed |", rows)
+	rows = nil
+	for _, c := range d.Effective {
+		rows = append(rows, []string{code(c.Organization), code(c.User), codes(c.Granted), codes(c.Revoked)})
+	}
+	writeTable("Effective permissions", "| Organization | User | Granted | Revoked |", rows)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func code(s string) string {
+	return "`" + s + "`"
+}
+
+func codes(names []string) string {
+	res := make([]string, len(names))
+	for i, name := range names {
+		res[i] = code(name)
+	}
+	return strings.Join(res, ", ")
+}
+
+type userKey struct {
+	organization, user string
+}
+
+func permissionNames(s *Snapshot) []string {
+	names := make([]string, 0, len(s.Permissions))
+	for _, p := range s.Permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func roleNames(s *Snapshot) []string {
+	names := make([]string, 0, len(s.Roles))
+	for _, r := range s.Roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func organizationNames(s *Snapshot) []string {
+	names := make([]string, 0, len(s.Organizations))
+	for _, o := range s.Organizations {
+		names = append(names, o.Name)
+	}
+	return names
+}
+
+func rolePermissions(s *Snapshot) map[string][]string {
+	res := make(map[string][]string, len(s.Roles))
+	for _, r := range s.Roles {
+		res[r.Name] = r.Permissions
+	}
+	return res
+}
+
+func users(s *Snapshot) map[userKey]User {
+	res := map[userKey]User{}
+	for _, o := range s.Organizations {
+		for _, u := range o.Users {
+			res[userKey{organization: o.Name, user: u.Key}] = u
+		}
+	}
+	return res
+}
+
+// effective is the set of permissions a user holds through its roles and its
+// directly attached permissions.
+func effective(u User, roles map[string][]string) []string {
+	names := append([]string{}, u.Permissions...)
+	for _, r := range u.Roles {
+		names = append(names, roles[r]...)
+	}
+	return names
+}
+
+// compareNames returns the sorted, deduplicated names only in after as
+// Added and only in before as Removed.
+func compareNames(before, after []string) Changes {
+	prev := toSet(before)
+	next := toSet(after)
+	c := Changes{Added: []string{}, Removed: []string{}}
+	for name := range next {
+		if !prev[name] {
+			c.Added = append(c.Added, name)
+		}
+	}
+	for name := range prev {
+		if !next[name] {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	return c
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,90 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/snapshot"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	srv := tests.NewServer()
+	defer srv.Close()
+	srv.AddPermission("create:test", "")
+	srv.AddPermission("read:test", "")
+	srv.AddRole("admin", "create:test", "read:test")
+	srv.AddRole("viewer", "read:test")
+	srv.AddOrganization("default")
+	srv.AddUser("default", "user1", "admin")
+	srv.AddUser("default", "user2", "viewer")
+	client := srv.Client(t)
+
+	before, err := snapshot.Export(ctx, client)
+	require.NoError(t, err)
+
+	srv.AddPermission("delete:test", "")
+	srv.AddRole("editor", "create:test", "read:test")
+	srv.AddUser("default", "user3", "editor")
+	after, err := snapshot.Export(ctx, client)
+	require.NoError(t, err)
+	after.Roles[0].Permissions = append(after.Roles[0].Permissions, "delete:test")
+	after.Organizations[0].Users[1].Roles = []string{"editor"}
+
+	d := snapshot.Compare(before, after)
+	cases := tests.Cases{
+		{
+			Name: "text",
+			Fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				require.NoError(t, d.WriteText(&buf))
+				assert.Equal(t, `+ permission delete:test
++ role editor
++ role admin permission delete:test
++ organization default user user2 role editor
+- organization default user user2 role viewer
++ organization default user user3 role editor
++ organization default user user1 can delete:test
++ organization default user user2 can create:test
++ organization default user user3 can create:test
++ organization default user user3 can read:test
+`, buf.String())
+			},
+		},
+		{
+			Name: "json",
+			Fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				require.NoError(t, d.WriteJSON(&buf))
+				var res snapshot.Diff
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+				assert.Equal(t, *d, res)
+			},
+		},
+		{
+			Name: "markdown",
+			Fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				require.NoError(t, d.WriteMarkdown(&buf))
+				assert.Contains(t, buf.String(), "| Organization | User | Granted | Revoked |\n| --- | --- | --- | --- |\n")
+				assert.Contains(t, buf.String(), "| `default` | `user3` | `create:test`, `read:test` |  |\n")
+			},
+		},
+		{
+			Name: "no changes",
+			Fn: func(t *testing.T) {
+				var buf bytes.Buffer
+				same := snapshot.Compare(before, before)
+				assert.True(t, same.Empty())
+				require.NoError(t, same.WriteText(&buf))
+				assert.Equal(t, "No access changes.\n", buf.String())
+			},
+		},
+	}
+	cases.Run(t)
+}
@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+type options struct {
+	progress ProgressFunc
+}
+
+type Option func(o *options)
+
+func WithProgress(fn ProgressFunc) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{progress: func(Progress) {}}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Export walks Permission.FindAll, Role.FindAll with Role.GetPermissions and
+// Organization.FindAll with its embedded users.
+func Export(ctx context.Context, client *rbns.Client, opts ...Option) (*Snapshot, error) {
+	o := newOptions(opts)
+	ctx = client.OutgoingContext(ctx)
+	con := client.Conn()
+	s := &Snapshot{
+		Version:       Version,
+		CreatedAt:     time.Now().UTC(),
+		Permissions:   []Permission{},
+		Roles:         []Role{},
+		Organizations: []Organization{},
+	}
+
+	permissions, err := proto.NewPermissionClient(con).FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range permissions.GetPermissions() {
+		s.Permissions = append(s.Permissions, Permission{ID: p.GetId(), Name: p.GetName(), Description: p.GetDescription()})
+		o.progress(Progress{Phase: "permissions", Name: p.GetName(), Done: i + 1, Total: len(permissions.GetPermissions())})
+	}
+
+	roleClient := proto.NewRoleClient(con)
+	roles, err := roleClient.FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range roles.GetRoles() {
+		rolePermissions, err := roleClient.GetPermissions(ctx, &proto.RoleKey{Id: r.GetId()})
+		if err != nil {
+			return nil, err
+		}
+		role := Role{ID: r.GetId(), Name: r.GetName(), Description: r.GetDescription()}
+		for _, p := range rolePermissions.GetPermissions() {
+			role.Permissions = append(role.Permissions, p.GetName())
+		}
+		s.Roles = append(s.Roles, role)
+		o.progress(Progress{Phase: "roles", Name: r.GetName(), Done: i + 1, Total: len(roles.GetRoles())})
+	}
+
+	organizations, err := proto.NewOrganizationClient(con).FindAll(ctx, &proto.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	for i, org := range organizations.GetOrganizations() {
+		organization := Organization{ID: org.GetId(), Name: org.GetName(), Description: org.GetDescription()}
+		for _, u := range org.GetUsers() {
+			user := User{Key: u.GetKey()}
+			for _, r := range u.GetRoles() {
+				user.Roles = append(user.Roles, r.GetName())
+			}
+			for _, p := range u.GetPermissions() {
+				user.Permissions = append(user.Permissions, p.GetName())
+			}
+			organization.Users = append(organization.Users, user)
+		}
+		s.Organizations = append(s.Organizations, organization)
+		o.progress(Progress{Phase: "organizations", Name: org.GetName(), Done: i + 1, Total: len(organizations.GetOrganizations())})
+	}
+	return s, nil
+}
@@ -0,0 +1,236 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/manifest"
+	"github.com/n-creativesystem/go-rbns/proto"
+)
+
+// Result describes a finished import. The id maps translate the ids recorded
+// in the snapshot to the ids of the same entities on the target server.
+type Result struct {
+	PermissionIDs   map[string]string
+	RoleIDs         map[string]string
+	OrganizationIDs map[string]string
+	Created         int
+	Skipped         int
+}
+
+// Import restores s into the server behind client. Entities are matched by
+// name: anything already present on the target is reused rather than
+// recreated, so an interrupted import can simply be run again to resume it.
+// Nothing on the target is deleted.
+func Import(ctx context.Context, client *rbns.Client, s *Snapshot, opts ...Option) (*Result, error) {
+	o := newOptions(opts)
+	state, err := manifest.Fetch(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	ctx = client.OutgoingContext(ctx)
+	con := client.Conn()
+	im := &importer{
+		permissions:   proto.NewPermissionClient(con),
+		roles:         proto.NewRoleClient(con),
+		organizations: proto.NewOrganizationClient(con),
+		users:         proto.NewUserClient(con),
+		state:         state,
+		progress:      o.progress,
+		result: &Result{
+			PermissionIDs:   map[string]string{},
+			RoleIDs:         map[string]string{},
+			OrganizationIDs: map[string]string{},
+		},
+	}
+	for _, step := range []func(context.Context, *Snapshot) error{
+		im.importPermissions,
+		im.importRoles,
+		im.importOrganizations,
+		im.importUsers,
+	} {
+		if err := step(ctx, s); err != nil {
+			return im.result, fmt.Errorf("snapshot: %w", err)
+		}
+	}
+	return im.result, nil
+}
+
+type importer struct {
+	permissions   proto.PermissionClient
+	roles         proto.RoleClient
+	organizations proto.OrganizationClient
+	users         proto.UserClient
+	state         *manifest.State
+	progress      ProgressFunc
+	result        *Result
+}
+
+func (im *importer) report(phase, name string, done, total int, skipped bool) {
+	if skipped {
+		im.result.Skipped++
+	} else {
+		im.result.Created++
+	}
+	im.progress(Progress{Phase: phase, Name: name, Done: done, Total: total, Skipped: skipped})
+}
+
+func (im *importer) permissionKeys(names []string) ([]*proto.PermissionKey, error) {
+	keys := make([]*proto.PermissionKey, 0, len(names))
+	for _, name := range names {
+		id, ok := im.state.PermissionIDs[name]
+		if !ok {
+			return nil, fmt.Errorf("permission %q is neither on the target server nor among the snapshot's permissions", name)
+		}
+		keys = append(keys, &proto.PermissionKey{Id: id})
+	}
+	return keys, nil
+}
+
+func (im *importer) importPermissions(ctx context.Context, s *Snapshot) error {
+	for i, p := range s.Permissions {
+		id, ok := im.state.PermissionIDs[p.Name]
+		if !ok {
+			res, err := im.permissions.Create(ctx, &proto.PermissionEntities{
+				Permissions: []*proto.PermissionEntity{{Name: p.Name, Description: p.Description}},
+			})
+			if err != nil {
+				return fmt.Errorf("permission %q: %w", p.Name, err)
+			}
+			if len(res.GetPermissions()) == 0 || res.GetPermissions()[0].GetId() == "" {
+				return fmt.Errorf("permission %q: server created no permission", p.Name)
+			}
+			id = res.GetPermissions()[0].GetId()
+			im.state.PermissionIDs[p.Name] = id
+		}
+		im.result.PermissionIDs[p.ID] = id
+		im.report("permissions", p.Name, i+1, len(s.Permissions), ok)
+	}
+	return nil
+}
+
+func (im *importer) importRoles(ctx context.Context, s *Snapshot) error {
+	current := map[string][]string{}
+	for _, r := range im.state.Roles {
+		current[r.Name] = r.Permissions
+	}
+	for i, r := range s.Roles {
+		id, ok := im.state.RoleIDs[r.Name]
+		if !ok {
+			res, err := im.roles.Create(ctx, &proto.RoleEntities{
+				Roles: []*proto.RoleEntity{{Name: r.Name, Description: r.Description}},
+			})
+			if err != nil {
+				return fmt.Errorf("role %q: %w", r.Name, err)
+			}
+			if len(res.GetRoles()) == 0 || res.GetRoles()[0].GetId() == "" {
+				return fmt.Errorf("role %q: server created no role", r.Name)
+			}
+			id = res.GetRoles()[0].GetId()
+			im.state.RoleIDs[r.Name] = id
+		}
+		im.result.RoleIDs[r.ID] = id
+		missing := missingNames(current[r.Name], r.Permissions)
+		if len(missing) > 0 {
+			keys, err := im.permissionKeys(missing)
+			if err != nil {
+				return fmt.Errorf("role %q: %w", r.Name, err)
+			}
+			if _, err := im.roles.AddPermissions(ctx, &proto.RoleReleationPermissions{Id: id, Permissions: keys}); err != nil {
+				return fmt.Errorf("role %q: %w", r.Name, err)
+			}
+		}
+		im.report("roles", r.Name, i+1, len(s.Roles), ok && len(missing) == 0)
+	}
+	return nil
+}
+
+func (im *importer) importOrganizations(ctx context.Context, s *Snapshot) error {
+	for i, org := range s.Organizations {
+		id, ok := im.state.OrganizationIDs[org.Name]
+		if !ok {
+			res, err := im.organizations.Create(ctx, &proto.OrganizationEntity{Name: org.Name, Description: org.Description})
+			if err != nil {
+				return fmt.Errorf("organization %q: %w", org.Name, err)
+			}
+			if res.GetId() == "" {
+				return fmt.Errorf("organization %q: server created no organization", org.Name)
+			}
+			id = res.GetId()
+			im.state.OrganizationIDs[org.Name] = id
+		}
+		im.result.OrganizationIDs[org.ID] = id
+		im.report("organizations", org.Name, i+1, len(s.Organizations), ok)
+	}
+	return nil
+}
+
+func (im *importer) importUsers(ctx context.Context, s *Snapshot) error {
+	current := map[string]map[string][]string{}
+	for _, org := range im.state.Organizations {
+		users := map[string][]string{}
+		for _, u := range org.Users {
+			users[u.Key] = u.Roles
+		}
+		current[org.Name] = users
+	}
+	total := 0
+	for _, org := range s.Organizations {
+		total += len(org.Users)
+	}
+	done := 0
+	for _, org := range s.Organizations {
+		organizationID := im.state.OrganizationIDs[org.Name]
+		for _, u := range org.Users {
+			done++
+			key := &proto.UserKey{Key: u.Key, OrganizationId: organizationID}
+			roles, exists := current[org.Name][u.Key]
+			if !exists {
+				permissions, err := im.permissionKeys(u.Permissions)
+				if err != nil {
+					return fmt.Errorf("user %q in organization %q: %w", u.Key, org.Name, err)
+				}
+				entity := &proto.UserEntity{Key: u.Key, OrganizationId: organizationID}
+				for _, p := range permissions {
+					entity.Permissions = append(entity.Permissions, &proto.PermissionEntity{Id: p.Id})
+				}
+				if _, err := im.users.Create(ctx, entity); err != nil {
+					return fmt.Errorf("user %q in organization %q: %w", u.Key, org.Name, err)
+				}
+			}
+			missing := missingNames(roles, u.Roles)
+			if len(missing) > 0 {
+				userRole := &proto.UserRole{User: key}
+				for _, name := range missing {
+					id, ok := im.state.RoleIDs[name]
+					if !ok {
+						return fmt.Errorf("user %q in organization %q: role %q is neither on the target server nor among the snapshot's roles", u.Key, org.Name, name)
+					}
+					userRole.Roles = append(userRole.Roles, &proto.RoleKey{Id: id})
+				}
+				if _, err := im.users.AddRole(ctx, userRole); err != nil {
+					return fmt.Errorf("user %q in organization %q: %w", u.Key, org.Name, err)
+				}
+			}
+			im.report("users", org.Name+"/"+u.Key, done, total, exists && len(missing) == 0)
+		}
+	}
+	return nil
+}
+
+// missingNames returns the names of want that are not in have.
+func missingNames(have, want []string) []string {
+	set := make(map[string]bool, len(have))
+	for _, name := range have {
+		set[name] = true
+	}
+	var missing []string
+	for _, name := range want {
+		if !set[name] {
+			missing = append(missing, name)
+			set[name] = true
+		}
+	}
+	return missing
+}
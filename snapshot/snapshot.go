@@ -0,0 +1,101 @@
+// Package snapshot exports the full state of an rbns server to a versioned
+// JSON document and restores it into another server.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Version is the document format written by this package.
+const Version = 1
+
+type Snapshot struct {
+	Version       int            `json:"version"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	Permissions   []Permission   `json:"permissions"`
+	Roles         []Role         `json:"roles"`
+	Organizations []Organization `json:"organizations"`
+}
+
+// Permission, Role and Organization keep the ids of the source server for
+// reference only; import matches entities by name.
+type Permission struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Role struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+type Organization struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Users       []User `json:"users,omitempty"`
+}
+
+type User struct {
+	Key         string   `json:"key"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Progress reports how far an export or import has got. Phase is one of
+// "permissions", "roles", "organizations" or "users"; Name is the entity
+// just handled and Skipped is set when import found it already present.
+type Progress struct {
+	Phase   string
+	Name    string
+	Done    int
+	Total   int
+	Skipped bool
+}
+
+type ProgressFunc func(p Progress)
+
+func Read(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("snapshot: unsupported version %d", s.Version)
+	}
+	return s, nil
+}
+
+func ReadFile(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+func (s *Snapshot) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,94 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/n-creativesystem/go-rbns/snapshot"
+	"github.com/n-creativesystem/go-rbns/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	source := tests.NewServer()
+	defer source.Close()
+	source.AddPermission("create:test", "create")
+	source.AddPermission("read:test", "read")
+	source.AddPermission("delete:test", "delete")
+	source.AddRole("admin", "create:test", "read:test", "delete:test")
+	source.AddRole("viewer", "read:test")
+	source.AddOrganization("default")
+	source.AddUser("default", "user1", "admin")
+	source.AddUser("default", "user2", "viewer")
+	source.AddUserPermission("default", "user2", "create:test")
+	sourceClient := source.Client(t)
+
+	target := tests.NewServer()
+	defer target.Close()
+	target.AddOrganization("other")
+	target.AddPermission("read:test", "read")
+	targetClient := target.Client(t)
+
+	var buf bytes.Buffer
+	cases := tests.Cases{
+		{
+			Name: "export",
+			Fn: func(t *testing.T) {
+				var phases []string
+				s, err := snapshot.Export(ctx, sourceClient, snapshot.WithProgress(func(p snapshot.Progress) {
+					phases = append(phases, p.Phase+":"+p.Name)
+				}))
+				require.NoError(t, err)
+				assert.Equal(t, []string{
+					"permissions:create:test", "permissions:read:test", "permissions:delete:test",
+					"roles:admin", "roles:viewer", "organizations:default",
+				}, phases)
+				assert.Equal(t, []string{"create:test"}, s.Organizations[0].Users[1].Permissions)
+				require.NoError(t, s.Write(&buf))
+			},
+		},
+		{
+			Name: "import",
+			Fn: func(t *testing.T) {
+				s, err := snapshot.Read(bytes.NewReader(buf.Bytes()))
+				require.NoError(t, err)
+				res, err := snapshot.Import(ctx, targetClient, s)
+				require.NoError(t, err)
+				assert.Equal(t, 1, res.Skipped)
+				assert.Equal(t, 7, res.Created)
+				assert.NotEqual(t, s.Roles[0].ID, res.RoleIDs[s.Roles[0].ID])
+
+				r, err := targetClient.Check("user1", "default", "create:test", "delete:test")
+				assert.NoError(t, err)
+				assert.True(t, r)
+				r, err = targetClient.Check("user2", "default", "create:test", "read:test")
+				assert.NoError(t, err)
+				assert.True(t, r)
+				r, err = targetClient.Check("user2", "default", "delete:test")
+				assert.NoError(t, err)
+				assert.False(t, r)
+			},
+		},
+		{
+			Name: "resume",
+			Fn: func(t *testing.T) {
+				s, err := snapshot.Read(bytes.NewReader(buf.Bytes()))
+				require.NoError(t, err)
+				res, err := snapshot.Import(ctx, targetClient, s)
+				require.NoError(t, err)
+				assert.Equal(t, 8, res.Skipped)
+				assert.Zero(t, res.Created)
+			},
+		},
+	}
+	cases.Run(t)
+}
+
+func TestReadVersion(t *testing.T) {
+	_, err := snapshot.Read(strings.NewReader(`{"version":2}`))
+	assert.EqualError(t, err, "snapshot: unsupported version 2")
+}
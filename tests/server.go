@@ -0,0 +1,745 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+
+	rbns "github.com/n-creativesystem/go-rbns"
+	"github.com/n-creativesystem/go-rbns/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Server is an in-memory rbns server for tests. It implements the
+// Organization, Permission, Role and User services plus the standard
+// health service over a bufconn listener.
+type Server struct {
+	mu       sync.Mutex
+	seq      int
+	perms    map[string]*proto.PermissionEntity
+	roles    map[string]*fakeRole
+	orgs     map[string]*fakeOrganization
+	order    map[string]int
+	checks   int
+	listener *bufconn.Listener
+	server   *grpc.Server
+	Health   *health.Server
+}
+
+type fakeRole struct {
+	id, name, description string
+	permissions           map[string]bool
+}
+
+type fakeOrganization struct {
+	id, name, description string
+	users                 map[string]*fakeUser
+}
+
+type fakeUser struct {
+	key         string
+	roles       map[string]bool
+	permissions map[string]bool
+}
+
+func NewServer() *Server {
+	s := &Server{
+		perms:    map[string]*proto.PermissionEntity{},
+		roles:    map[string]*fakeRole{},
+		orgs:     map[string]*fakeOrganization{},
+		order:    map[string]int{},
+		listener: bufconn.Listen(1024 * 1024),
+		server:   grpc.NewServer(),
+		Health:   health.NewServer(),
+	}
+	proto.RegisterOrganizationServer(s.server, &organizationServer{s: s})
+	proto.RegisterPermissionServer(s.server, &permissionServer{s: s})
+	proto.RegisterRoleServer(s.server, &roleServer{s: s})
+	proto.RegisterUserServer(s.server, &userServer{s: s})
+	healthpb.RegisterHealthServer(s.server, s.Health)
+	go func() {
+		_ = s.server.Serve(s.listener)
+	}()
+	return s
+}
+
+// Addr is the target to pass to rbns.WithHost together with DialOptions.
+func (s *Server) Addr() string {
+	return "bufnet"
+}
+
+func (s *Server) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(s.Dial),
+		grpc.WithInsecure(),
+	}
+}
+
+// Client connects a client to the server, failing the test when it cannot,
+// and closes it when the test ends. opts are applied after the server's
+// host and dial options.
+func (s *Server) Client(t testing.TB, opts ...rbns.Option) *rbns.Client {
+	t.Helper()
+	client, err := rbns.Connection(context.Background(), append([]rbns.Option{
+		rbns.WithHost(s.Addr()),
+		rbns.WithDialOption(s.DialOptions()...),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("tests: connect: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// Dial connects to the server whatever the address, for use in a dialer
+// routing several servers.
+func (s *Server) Dial(context.Context, string) (net.Conn, error) {
+	return s.listener.Dial()
+}
+
+// Checks is the number of Permission.Check calls served.
+func (s *Server) Checks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checks
+}
+
+func (s *Server) Close() {
+	s.server.Stop()
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	id := fmt.Sprintf("%s-%d", prefix, s.seq)
+	s.order[id] = s.seq
+	return id
+}
+
+func (s *Server) sortIDs(ids []string) {
+	sort.Slice(ids, func(i, j int) bool { return s.order[ids[i]] < s.order[ids[j]] })
+}
+
+func (s *Server) permissionByName(name string) *proto.PermissionEntity {
+	for _, p := range s.perms {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) roleByName(name string) *fakeRole {
+	for _, r := range s.roles {
+		if r.name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Server) organizationByName(name string) *fakeOrganization {
+	for _, o := range s.orgs {
+		if o.name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Server) permissionEntities(ids map[string]bool) []*proto.PermissionEntity {
+	keys := make([]string, 0, len(ids))
+	for id := range ids {
+		if _, ok := s.perms[id]; ok {
+			keys = append(keys, id)
+		}
+	}
+	s.sortIDs(keys)
+	res := make([]*proto.PermissionEntity, 0, len(keys))
+	for _, id := range keys {
+		p := s.perms[id]
+		res = append(res, &proto.PermissionEntity{Id: p.Id, Name: p.Name, Description: p.Description})
+	}
+	return res
+}
+
+func (s *Server) roleEntity(r *fakeRole) *proto.RoleEntity {
+	entity := &proto.RoleEntity{
+		Id:          r.id,
+		Name:        r.name,
+		Description: r.description,
+		Permissions: s.permissionEntities(r.permissions),
+	}
+	for _, o := range s.sortedOrganizations() {
+		for _, u := range s.sortedUsers(o) {
+			if u.roles[r.id] {
+				entity.OrganizationUsers = append(entity.OrganizationUsers, &proto.OrganizationUser{
+					UserKey:                 u.key,
+					OrganizationId:          o.id,
+					OrganizationName:        o.name,
+					OrganizationDescription: o.description,
+				})
+			}
+		}
+	}
+	return entity
+}
+
+func (s *Server) userEntity(o *fakeOrganization, u *fakeUser) *proto.UserEntity {
+	entity := &proto.UserEntity{
+		Key:            u.key,
+		OrganizationId: o.id,
+		Permissions:    s.permissionEntities(u.permissions),
+	}
+	ids := make([]string, 0, len(u.roles))
+	for id := range u.roles {
+		if _, ok := s.roles[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	s.sortIDs(ids)
+	for _, id := range ids {
+		r := s.roles[id]
+		entity.Roles = append(entity.Roles, &proto.RoleEntity{
+			Id:          r.id,
+			Name:        r.name,
+			Description: r.description,
+			Permissions: s.permissionEntities(r.permissions),
+		})
+	}
+	return entity
+}
+
+func (s *Server) organizationEntity(o *fakeOrganization) *proto.OrganizationEntity {
+	entity := &proto.OrganizationEntity{
+		Id:          o.id,
+		Name:        o.name,
+		Description: o.description,
+	}
+	for _, u := range s.sortedUsers(o) {
+		entity.Users = append(entity.Users, s.userEntity(o, u))
+	}
+	return entity
+}
+
+func (s *Server) sortedOrganizations() []*fakeOrganization {
+	ids := make([]string, 0, len(s.orgs))
+	for id := range s.orgs {
+		ids = append(ids, id)
+	}
+	s.sortIDs(ids)
+	res := make([]*fakeOrganization, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, s.orgs[id])
+	}
+	return res
+}
+
+func (s *Server) sortedUsers(o *fakeOrganization) []*fakeUser {
+	keys := make([]string, 0, len(o.users))
+	for key := range o.users {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.order[o.id+"/"+keys[i]] < s.order[o.id+"/"+keys[j]]
+	})
+	res := make([]*fakeUser, 0, len(keys))
+	for _, key := range keys {
+		res = append(res, o.users[key])
+	}
+	return res
+}
+
+func (s *Server) lookupUser(key *proto.UserKey) (*fakeOrganization, *fakeUser, error) {
+	o, ok := s.orgs[key.GetOrganizationId()]
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "organization %q not found", key.GetOrganizationId())
+	}
+	u, ok := o.users[key.GetKey()]
+	if !ok {
+		return nil, nil, status.Errorf(codes.NotFound, "user %q not found", key.GetKey())
+	}
+	return o, u, nil
+}
+
+type organizationServer struct {
+	s *Server
+	proto.UnimplementedOrganizationServer
+}
+
+func (srv *organizationServer) Create(ctx context.Context, in *proto.OrganizationEntity) (*proto.OrganizationEntity, error) {
+	s := srv.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if s.organizationByName(in.GetName()) != nil {
+		return nil, status.Errorf(codes.AlreadyExists, "organization %q already exists", in.GetName())
+	}
+	o := &fakeOrganization{
+		id:          s.nextID("organization"),
+		name:        in.GetName(),
+		description: in.GetDescription(),
+		users:       map[string]*fakeUser{},
+	}
+	s.orgs[o.id] = o
+	return s.organizationEntity(o), nil
+}
+
+func (srv *organizationServer) FindById(ctx context.Context, in *proto.OrganizationKey) (*proto.OrganizationEntity, error) {
+	s := srv.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orgs[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "organization %q not found", in.GetId())
+	}
+	return s.organizationEntity(o), nil
+}
+
+func (srv *organizationServer) FindAll(ctx context.Context, in *proto.Empty) (*proto.OrganizationEntities, error) {
+	s := srv.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := &proto.OrganizationEntities{}
+	for _, o := range s.sortedOrganizations() {
+		res.Organizations = append(res.Organizations, s.organizationEntity(o))
+	}
+	return res, nil
+}
+
+func (srv *organizationServer) Update(ctx context.Context, in *proto.OrganizationUpdateEntity) (*proto.Empty, error) {
+	s := srv.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orgs[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "organization %q not found", in.GetId())
+	}
+	o.name = in.GetName()
+	o.description = in.GetDescription()
+	return &proto.Empty{}, nil
+}
+
+func (srv *organizationServer) Delete(ctx context.Context, in *proto.OrganizationKey) (*proto.Empty, error) {
+	s := srv.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orgs[in.GetId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "organization %q not found", in.GetId())
+	}
+	delete(s.orgs, in.GetId())
+	return &proto.Empty{}, nil
+}
+
+type permissionServer struct {
+	s *Server
+	proto.UnimplementedPermissionServer
+}
+
+func (p *permissionServer) Create(ctx context.Context, in *proto.PermissionEntities) (*proto.PermissionEntities, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range in.GetPermissions() {
+		if e.GetName() == "" {
+			return nil, status.Error(codes.InvalidArgument, "name is required")
+		}
+		if s.permissionByName(e.GetName()) != nil {
+			return nil, status.Errorf(codes.AlreadyExists, "permission %q already exists", e.GetName())
+		}
+	}
+	res := &proto.PermissionEntities{}
+	for _, e := range in.GetPermissions() {
+		entity := &proto.PermissionEntity{Id: s.nextID("permission"), Name: e.GetName(), Description: e.GetDescription()}
+		s.perms[entity.Id] = entity
+		res.Permissions = append(res.Permissions, &proto.PermissionEntity{Id: entity.Id, Name: entity.Name, Description: entity.Description})
+	}
+	return res, nil
+}
+
+func (p *permissionServer) FindById(ctx context.Context, in *proto.PermissionKey) (*proto.PermissionEntity, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.perms[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "permission %q not found", in.GetId())
+	}
+	return &proto.PermissionEntity{Id: e.Id, Name: e.Name, Description: e.Description}, nil
+}
+
+func (p *permissionServer) FindAll(ctx context.Context, in *proto.Empty) (*proto.PermissionEntities, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := map[string]bool{}
+	for id := range s.perms {
+		ids[id] = true
+	}
+	return &proto.PermissionEntities{Permissions: s.permissionEntities(ids)}, nil
+}
+
+func (p *permissionServer) Update(ctx context.Context, in *proto.PermissionEntity) (*proto.Empty, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.perms[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "permission %q not found", in.GetId())
+	}
+	e.Name = in.GetName()
+	e.Description = in.GetDescription()
+	return &proto.Empty{}, nil
+}
+
+func (p *permissionServer) Delete(ctx context.Context, in *proto.PermissionKey) (*proto.Empty, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.perms[in.GetId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "permission %q not found", in.GetId())
+	}
+	delete(s.perms, in.GetId())
+	for _, r := range s.roles {
+		delete(r.permissions, in.GetId())
+	}
+	for _, o := range s.orgs {
+		for _, u := range o.users {
+			delete(u.permissions, in.GetId())
+		}
+	}
+	return &proto.Empty{}, nil
+}
+
+func (p *permissionServer) Check(ctx context.Context, in *proto.PermissionCheckRequest) (*proto.PermissionCheckResult, error) {
+	s := p.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks++
+	o := s.organizationByName(in.GetOrganizationName())
+	if o == nil {
+		return &proto.PermissionCheckResult{Result: false, Message: "organization not found"}, nil
+	}
+	u, ok := o.users[in.GetUserKey()]
+	if !ok {
+		return &proto.PermissionCheckResult{Result: false, Message: "user not found"}, nil
+	}
+	granted := map[string]bool{}
+	for id := range u.permissions {
+		if e, ok := s.perms[id]; ok {
+			granted[e.Name] = true
+		}
+	}
+	for roleID := range u.roles {
+		if r, ok := s.roles[roleID]; ok {
+			for id := range r.permissions {
+				if e, ok := s.perms[id]; ok {
+					granted[e.Name] = true
+				}
+			}
+		}
+	}
+	for _, name := range in.GetPermissionNames() {
+		if !granted[name] {
+			return &proto.PermissionCheckResult{Result: false, Message: "permission denied"}, nil
+		}
+	}
+	return &proto.PermissionCheckResult{Result: true}, nil
+}
+
+type roleServer struct {
+	s *Server
+	proto.UnimplementedRoleServer
+}
+
+func (r *roleServer) Create(ctx context.Context, in *proto.RoleEntities) (*proto.RoleEntities, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range in.GetRoles() {
+		if e.GetName() == "" {
+			return nil, status.Error(codes.InvalidArgument, "name is required")
+		}
+		if s.roleByName(e.GetName()) != nil {
+			return nil, status.Errorf(codes.AlreadyExists, "role %q already exists", e.GetName())
+		}
+	}
+	res := &proto.RoleEntities{}
+	for _, e := range in.GetRoles() {
+		role := &fakeRole{id: s.nextID("role"), name: e.GetName(), description: e.GetDescription(), permissions: map[string]bool{}}
+		for _, p := range e.GetPermissions() {
+			if _, ok := s.perms[p.GetId()]; ok {
+				role.permissions[p.GetId()] = true
+			}
+		}
+		s.roles[role.id] = role
+		res.Roles = append(res.Roles, s.roleEntity(role))
+	}
+	return res, nil
+}
+
+func (r *roleServer) FindById(ctx context.Context, in *proto.RoleKey) (*proto.RoleEntity, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	return s.roleEntity(role), nil
+}
+
+func (r *roleServer) FindAll(ctx context.Context, in *proto.Empty) (*proto.RoleEntities, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.roles))
+	for id := range s.roles {
+		ids = append(ids, id)
+	}
+	s.sortIDs(ids)
+	res := &proto.RoleEntities{}
+	for _, id := range ids {
+		res.Roles = append(res.Roles, s.roleEntity(s.roles[id]))
+	}
+	return res, nil
+}
+
+func (r *roleServer) Update(ctx context.Context, in *proto.RoleUpdateEntity) (*proto.Empty, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	role.name = in.GetName()
+	role.description = in.GetDescription()
+	return &proto.Empty{}, nil
+}
+
+func (r *roleServer) Delete(ctx context.Context, in *proto.RoleKey) (*proto.Empty, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[in.GetId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	delete(s.roles, in.GetId())
+	for _, o := range s.orgs {
+		for _, u := range o.users {
+			delete(u.roles, in.GetId())
+		}
+	}
+	return &proto.Empty{}, nil
+}
+
+func (r *roleServer) GetPermissions(ctx context.Context, in *proto.RoleKey) (*proto.PermissionEntities, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	return &proto.PermissionEntities{Permissions: s.permissionEntities(role.permissions)}, nil
+}
+
+func (r *roleServer) AddPermissions(ctx context.Context, in *proto.RoleReleationPermissions) (*proto.Empty, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	for _, p := range in.GetPermissions() {
+		if _, ok := s.perms[p.GetId()]; !ok {
+			return nil, status.Errorf(codes.NotFound, "permission %q not found", p.GetId())
+		}
+	}
+	for _, p := range in.GetPermissions() {
+		role.permissions[p.GetId()] = true
+	}
+	return &proto.Empty{}, nil
+}
+
+func (r *roleServer) DeletePermission(ctx context.Context, in *proto.RoleReleationPermissions) (*proto.Empty, error) {
+	s := r.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[in.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "role %q not found", in.GetId())
+	}
+	for _, p := range in.GetPermissions() {
+		delete(role.permissions, p.GetId())
+	}
+	return &proto.Empty{}, nil
+}
+
+type userServer struct {
+	s *Server
+	proto.UnimplementedUserServer
+}
+
+func (u *userServer) Create(ctx context.Context, in *proto.UserEntity) (*proto.Empty, error) {
+	s := u.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.orgs[in.GetOrganizationId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "organization %q not found", in.GetOrganizationId())
+	}
+	if in.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	if _, ok := o.users[in.GetKey()]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "user %q already exists", in.GetKey())
+	}
+	user := &fakeUser{key: in.GetKey(), roles: map[string]bool{}, permissions: map[string]bool{}}
+	for _, r := range in.GetRoles() {
+		if _, ok := s.roles[r.GetId()]; ok {
+			user.roles[r.GetId()] = true
+		}
+	}
+	for _, p := range in.GetPermissions() {
+		if _, ok := s.perms[p.GetId()]; ok {
+			user.permissions[p.GetId()] = true
+		}
+	}
+	o.users[user.key] = user
+	s.seq++
+	s.order[o.id+"/"+user.key] = s.seq
+	return &proto.Empty{}, nil
+}
+
+func (u *userServer) Delete(ctx context.Context, in *proto.UserKey) (*proto.Empty, error) {
+	s := u.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, _, err := s.lookupUser(in)
+	if err != nil {
+		return nil, err
+	}
+	delete(o.users, in.GetKey())
+	return &proto.Empty{}, nil
+}
+
+func (u *userServer) FindByKey(ctx context.Context, in *proto.UserKey) (*proto.UserEntity, error) {
+	s := u.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, user, err := s.lookupUser(in)
+	if err != nil {
+		return nil, err
+	}
+	return s.userEntity(o, user), nil
+}
+
+func (u *userServer) AddRole(ctx context.Context, in *proto.UserRole) (*proto.Empty, error) {
+	s := u.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, user, err := s.lookupUser(in.GetUser())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range in.GetRoles() {
+		if _, ok := s.roles[r.GetId()]; !ok {
+			return nil, status.Errorf(codes.NotFound, "role %q not found", r.GetId())
+		}
+	}
+	for _, r := range in.GetRoles() {
+		user.roles[r.GetId()] = true
+	}
+	return &proto.Empty{}, nil
+}
+
+func (u *userServer) DeleteRole(ctx context.Context, in *proto.UserRole) (*proto.Empty, error) {
+	s := u.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, user, err := s.lookupUser(in.GetUser())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range in.GetRoles() {
+		delete(user.roles, r.GetId())
+	}
+	return &proto.Empty{}, nil
+}
+
+// AddPermission registers a permission directly and returns its id.
+func (s *Server) AddPermission(name, description string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &proto.PermissionEntity{Id: s.nextID("permission"), Name: name, Description: description}
+	s.perms[e.Id] = e
+	return e.Id
+}
+
+// AddRole registers a role granting the named permissions and returns its id.
+func (s *Server) AddRole(name string, permissionNames ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := &fakeRole{id: s.nextID("role"), name: name, permissions: map[string]bool{}}
+	for _, pn := range permissionNames {
+		if p := s.permissionByName(pn); p != nil {
+			r.permissions[p.Id] = true
+		}
+	}
+	s.roles[r.id] = r
+	return r.id
+}
+
+// AddOrganization registers an organization and returns its id.
+func (s *Server) AddOrganization(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := &fakeOrganization{id: s.nextID("organization"), name: name, users: map[string]*fakeUser{}}
+	s.orgs[o.id] = o
+	return o.id
+}
+
+// AddUser registers a user in the named organization bound to the named roles.
+func (s *Server) AddUser(organizationName, key string, roleNames ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.organizationByName(organizationName)
+	if o == nil {
+		return
+	}
+	u := &fakeUser{key: key, roles: map[string]bool{}, permissions: map[string]bool{}}
+	for _, rn := range roleNames {
+		if r := s.roleByName(rn); r != nil {
+			u.roles[r.id] = true
+		}
+	}
+	o.users[key] = u
+	s.seq++
+	s.order[o.id+"/"+key] = s.seq
+}
+
+// AddUserPermission attaches a permission directly to a user.
+func (s *Server) AddUserPermission(organizationName, key, permissionName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.organizationByName(organizationName)
+	p := s.permissionByName(permissionName)
+	if o == nil || p == nil {
+		return
+	}
+	if u, ok := o.users[key]; ok {
+		u.permissions[p.Id] = true
+	}
+}
@@ -0,0 +1,101 @@
+package rbns
+
+import "sort"
+
+// Permission is a permission name as a typed constant, as generated by
+// rbns-gen, so that typos fail to compile instead of being denied.
+type Permission string
+
+func (p Permission) String() string {
+	return string(p)
+}
+
+// Role is a role name as a typed constant.
+type Role string
+
+func (r Role) String() string {
+	return string(r)
+}
+
+// PermissionNames converts typed permissions to the names taken by Check.
+func PermissionNames(permissions ...Permission) []string {
+	names := make([]string, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return names
+}
+
+type PermissionInfo struct {
+	Permission  Permission
+	Description string
+}
+
+// RoleInfo describes a role with its effective permissions, inherited ones
+// included.
+type RoleInfo struct {
+	Role        Role
+	Description string
+	Permissions []Permission
+}
+
+// Registry lists the permissions and roles known when the constants were
+// generated.
+type Registry struct {
+	permissions []PermissionInfo
+	roles       []RoleInfo
+	byName      map[Permission]int
+	roleByName  map[Role]int
+}
+
+func NewRegistry(permissions []PermissionInfo, roles []RoleInfo) *Registry {
+	r := &Registry{
+		permissions: permissions,
+		roles:       roles,
+		byName:      make(map[Permission]int, len(permissions)),
+		roleByName:  make(map[Role]int, len(roles)),
+	}
+	for i, p := range permissions {
+		r.byName[p.Permission] = i
+	}
+	for i, role := range roles {
+		r.roleByName[role.Role] = i
+	}
+	return r
+}
+
+// Permissions returns every permission sorted by name.
+func (r *Registry) Permissions() []PermissionInfo {
+	res := append([]PermissionInfo{}, r.permissions...)
+	sort.Slice(res, func(i, j int) bool { return res[i].Permission < res[j].Permission })
+	return res
+}
+
+// Roles returns every role sorted by name.
+func (r *Registry) Roles() []RoleInfo {
+	res := append([]RoleInfo{}, r.roles...)
+	sort.Slice(res, func(i, j int) bool { return res[i].Role < res[j].Role })
+	return res
+}
+
+func (r *Registry) Permission(name string) (PermissionInfo, bool) {
+	i, ok := r.byName[Permission(name)]
+	if !ok {
+		return PermissionInfo{}, false
+	}
+	return r.permissions[i], true
+}
+
+func (r *Registry) Role(name string) (RoleInfo, bool) {
+	i, ok := r.roleByName[Role(name)]
+	if !ok {
+		return RoleInfo{}, false
+	}
+	return r.roles[i], true
+}
+
+// Describe returns the description of a permission, or "" when unknown.
+func (r *Registry) Describe(p Permission) string {
+	info, _ := r.Permission(string(p))
+	return info.Description
+}
@@ -0,0 +1,29 @@
+package rbns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	const (
+		readTest   Permission = "read:test"
+		createTest Permission = "create:test"
+		roleEditor Role       = "editor"
+	)
+	r := NewRegistry(
+		[]PermissionInfo{{Permission: readTest, Description: "read"}, {Permission: createTest}},
+		[]RoleInfo{{Role: roleEditor, Permissions: []Permission{createTest, readTest}}},
+	)
+	assert.Equal(t, []string{"read:test", "create:test"}, PermissionNames(readTest, createTest))
+	assert.Equal(t, []PermissionInfo{{Permission: createTest}, {Permission: readTest, Description: "read"}}, r.Permissions())
+	assert.Equal(t, "read", r.Describe(readTest))
+	assert.Equal(t, "", r.Describe("delete:test"))
+	_, ok := r.Permission("delete:test")
+	assert.False(t, ok)
+	role, ok := r.Role("editor")
+	assert.True(t, ok)
+	assert.Equal(t, []Permission{createTest, readTest}, role.Permissions)
+	assert.Len(t, r.Roles(), 1)
+}